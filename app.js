@@ -299,6 +299,7 @@ app.post('/channels/:channelName/chaincodes', async function (req, res) {
 	var chaincodeType = req.body.chaincodeType;
 	var fcn = req.body.fcn;
 	var args = req.body.args;
+	var collectionsConfigPath = req.body.collectionsConfigPath;
 	logger.debug('peers  : ' + peers);
 	logger.debug('channelName  : ' + channelName);
 	logger.debug('chaincodeName : ' + chaincodeName);
@@ -306,6 +307,7 @@ app.post('/channels/:channelName/chaincodes', async function (req, res) {
 	logger.debug('chaincodeType  : ' + chaincodeType);
 	logger.debug('fcn  : ' + fcn);
 	logger.debug('args  : ' + args);
+	logger.debug('collectionsConfigPath  : ' + collectionsConfigPath);
 	if (!chaincodeName) {
 		res.json(getErrorMessage('\'chaincodeName\''));
 		return;
@@ -327,7 +329,7 @@ app.post('/channels/:channelName/chaincodes', async function (req, res) {
 		return;
 	}
 
-	let message = await instantiate.instantiateChaincode(peers, channelName, chaincodeName, chaincodeVersion, chaincodeType, fcn, args, req.username, req.orgname);
+	let message = await instantiate.instantiateChaincode(peers, channelName, chaincodeName, chaincodeVersion, fcn, chaincodeType, args, req.username, req.orgname, collectionsConfigPath);
 	res.send(message);
 });
 
@@ -341,6 +343,7 @@ app.post('/channels/:channelName/upgradeChaincodes', async function (req, res) {
 	var chaincodeType = req.body.chaincodeType;
 	var fcn = req.body.fcn;
 	var args = req.body.args;
+	var collectionsConfigPath = req.body.collectionsConfigPath;
 	logger.debug('peers  : ' + peers);
 	logger.debug('channelName  : ' + channelName);
 	logger.debug('chaincodeName : ' + chaincodeName);
@@ -348,6 +351,7 @@ app.post('/channels/:channelName/upgradeChaincodes', async function (req, res) {
 	logger.debug('chaincodeType  : ' + chaincodeType);
 	logger.debug('fcn  : ' + fcn);
 	logger.debug('args  : ' + args);
+	logger.debug('collectionsConfigPath  : ' + collectionsConfigPath);
 	if (!chaincodeName) {
 		res.json(getErrorMessage('\'chaincodeName\''));
 		return;
@@ -369,7 +373,7 @@ app.post('/channels/:channelName/upgradeChaincodes', async function (req, res) {
 		return;
 	}
 
-	let message = await upgrade.upgradeChaincode(peers, channelName, chaincodeName, chaincodeVersion, chaincodeType, fcn, args, req.username, req.orgname);
+	let message = await upgrade.upgradeChaincode(peers, channelName, chaincodeName, chaincodeVersion, fcn, chaincodeType, args, req.username, req.orgname, collectionsConfigPath);
 	res.send(message);
 });
 
@@ -381,6 +385,8 @@ app.post('/channels/:channelName/chaincodes/:chaincodeName', async function (req
 	var channelName = req.params.channelName;
 	var fcn = req.body.fcn;
 	var args = req.body.args;
+	// transient data is not logged, it holds private data
+	var transient = req.body.transient;
 	logger.debug('channelName  : ' + channelName);
 	logger.debug('chaincodeName : ' + chaincodeName);
 	logger.debug('fcn  : ' + fcn);
@@ -402,7 +408,7 @@ app.post('/channels/:channelName/chaincodes/:chaincodeName', async function (req
 		return;
 	}
 	try {
-		let message = await invoke.invokeChaincode(peers, channelName, chaincodeName, fcn, args, req.username, req.orgname);
+		let message = await invoke.invokeChaincode(peers, channelName, chaincodeName, fcn, args, transient, req.username, req.orgname);
 		res.send(message);
 	} catch (err) {
 		let response = {
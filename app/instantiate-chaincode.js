@@ -14,11 +14,12 @@
  *  limitations under the License.
  */
 'use strict';
+var path = require('path');
 var util = require('util');
 var helper = require('./helper.js');
 var logger = helper.getLogger('instantiate-chaincode');
 
-var instantiateChaincode = async function (peers, channelName, chaincodeName, chaincodeVersion, functionName, chaincodeType, args, username, org_name) {
+var instantiateChaincode = async function (peers, channelName, chaincodeName, chaincodeVersion, functionName, chaincodeType, args, username, org_name, collectionsConfigPath) {
 	logger.debug('\n\n============ Instantiate chaincode on channel ' + channelName +
 		' ============\n');
 	var error_message = null;
@@ -66,6 +67,10 @@ var instantiateChaincode = async function (peers, channelName, chaincodeName, ch
 
 		if (functionName)
 			request.fcn = functionName;
+		// private data collections (e.g. the recipients collections of the
+		// certificates chaincode)
+		if (collectionsConfigPath)
+			request['collections-config'] = path.join(__dirname, collectionsConfigPath);
 
 		let results = await channel.sendInstantiateProposal(request, 60000); //instantiate takes much longer
 
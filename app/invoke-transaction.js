@@ -18,7 +18,7 @@ var util = require('util');
 var helper = require('./helper.js');
 var logger = helper.getLogger('invoke-chaincode');
 
-var invokeChaincode = async function (peerNames, channelName, chaincodeName, fcn, args, username, org_name) {
+var invokeChaincode = async function (peerNames, channelName, chaincodeName, fcn, args, transient, username, org_name) {
 	logger.debug(util.format('\n============ invoke transaction on channel %s ============\n', channelName));
 	var error_message = null;
 	var tx_id_string = null;
@@ -45,6 +45,15 @@ var invokeChaincode = async function (peerNames, channelName, chaincodeName, fcn
 			chainId: channelName,
 			txId: tx_id
 		};
+		// private data (e.g. the recipient of issueCertificate), sent to the
+		// endorsers only, it isn't written to the transaction
+		if (transient) {
+			request.transientMap = {};
+			for (let key in transient) {
+				let value = transient[key];
+				request.transientMap[key] = Buffer.from(typeof value === 'string' ? value : JSON.stringify(value));
+			}
+		}
 
 		let results = await channel.sendTransactionProposal(request);
 
@@ -14,11 +14,12 @@
  *  limitations under the License.
  */
 'use strict';
+var path = require('path');
 var util = require('util');
 var helper = require('./helper.js');
 var logger = helper.getLogger('upgrade-chaincode');
 
-var upgradeChaincode = async function (peers, channelName, chaincodeName, chaincodeVersion, functionName, chaincodeType, args, username, org_name) {
+var upgradeChaincode = async function (peers, channelName, chaincodeName, chaincodeVersion, functionName, chaincodeType, args, username, org_name, collectionsConfigPath) {
 	logger.debug('\n\n============ Upgrade chaincode on channel ' + channelName +
 		' ============\n');
 	var error_message = null;
@@ -66,6 +67,10 @@ var upgradeChaincode = async function (peers, channelName, chaincodeName, chainc
 
 		if (functionName)
 			request.fcn = functionName;
+		// private data collections (e.g. the recipients collections of the
+		// certificates chaincode)
+		if (collectionsConfigPath)
+			request['collections-config'] = path.join(__dirname, collectionsConfigPath);
 
 		let results = await channel.sendUpgradeProposal(request, 60000); //upgrade takes much longer
 
@@ -0,0 +1,190 @@
+// Package canonicaljson serializes JSON with the JSON Canonicalization
+// Scheme (RFC 8785), so that a document has a single byte representation
+// and its digest can be computed again by any RFC 8785 implementation:
+//
+//	out, err := canonicaljson.Marshal(cert)
+//	sum := sha256.Sum256(out)
+//
+// Object members are sorted by the UTF-16 code units of their names,
+// strings are escaped like ECMAScript JSON.stringify (only '"', '\' and
+// control characters) and numbers are formatted like ECMAScript Number
+// serialization (IEEE 754 double, shortest round trip). There is no
+// whitespace.
+package canonicaljson
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// Marshal returns the canonical JSON encoding of v (encoded with
+// encoding/json first, so struct tags apply)
+func Marshal(v interface{}) ([]byte, error) {
+	out, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return Transform(out)
+}
+
+// Transform returns the canonical form of a JSON document
+func Transform(data []byte) ([]byte, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var v interface{}
+	err := decoder.Decode(&v)
+	if err != nil {
+		return nil, err
+	}
+	if decoder.More() {
+		return nil, errors.New("canonicaljson: data after the top-level value")
+	}
+
+	var buf bytes.Buffer
+	err = encode(&buf, v)
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func encode(buf *bytes.Buffer, v interface{}) error {
+	switch v := v.(type) {
+	case nil:
+		buf.WriteString("null")
+	case bool:
+		buf.WriteString(strconv.FormatBool(v))
+	case json.Number:
+		f, err := strconv.ParseFloat(string(v), 64)
+		if err != nil {
+			return errors.New("canonicaljson: number " + string(v) + " is out of range")
+		}
+		s, err := FormatNumber(f)
+		if err != nil {
+			return err
+		}
+		buf.WriteString(s)
+	case string:
+		encodeString(buf, v)
+	case []interface{}:
+		buf.WriteByte('[')
+		for i, elem := range v {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			err := encode(buf, elem)
+			if err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+	case map[string]interface{}:
+		names := make([]string, 0, len(v))
+		for name := range v {
+			names = append(names, name)
+		}
+		sort.Sort(byUTF16(names))
+
+		buf.WriteByte('{')
+		for i, name := range names {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			encodeString(buf, name)
+			buf.WriteByte(':')
+			err := encode(buf, v[name])
+			if err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+	default:
+		return errors.New("canonicaljson: unexpected value")
+	}
+	return nil
+}
+
+// FormatNumber formats a number like ECMAScript Number.prototype.toString
+// (RFC 8785 section 3.2.2.3). NaN and infinities are not valid JSON.
+func FormatNumber(f float64) (string, error) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return "", errors.New("canonicaljson: NaN and infinity are not valid JSON numbers")
+	}
+	// also -0
+	if f == 0 {
+		return "0", nil
+	}
+
+	sign := ""
+	if f < 0 {
+		f, sign = -f, "-"
+	}
+
+	format := byte('e')
+	if f >= 1e-6 && f < 1e21 {
+		format = 'f'
+	}
+	s := strconv.FormatFloat(f, format, -1, 64)
+
+	// ECMAScript exponents have no leading zero: 1e-07 is 1e-7
+	if e := strings.IndexByte(s, 'e'); e > 0 && s[e+2] == '0' {
+		s = s[:e+2] + s[e+3:]
+	}
+	return sign + s, nil
+}
+
+const hexDigits = "0123456789abcdef"
+
+func encodeString(buf *bytes.Buffer, s string) {
+	buf.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			buf.WriteString(`\"`)
+		case '\\':
+			buf.WriteString(`\\`)
+		case '\b':
+			buf.WriteString(`\b`)
+		case '\f':
+			buf.WriteString(`\f`)
+		case '\n':
+			buf.WriteString(`\n`)
+		case '\r':
+			buf.WriteString(`\r`)
+		case '\t':
+			buf.WriteString(`\t`)
+		default:
+			if r < 0x20 {
+				buf.WriteString(`\u00`)
+				buf.WriteByte(hexDigits[r>>4])
+				buf.WriteByte(hexDigits[r&0xF])
+			} else {
+				buf.WriteRune(r)
+			}
+		}
+	}
+	buf.WriteByte('"')
+}
+
+// byUTF16 sorts strings by their UTF-16 code units (RFC 8785 section
+// 3.2.3), which differs from the UTF-8 byte order above U+FFFF
+type byUTF16 []string
+
+func (s byUTF16) Len() int      { return len(s) }
+func (s byUTF16) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s byUTF16) Less(i, j int) bool {
+	a, b := utf16.Encode([]rune(s[i])), utf16.Encode([]rune(s[j]))
+	for k := 0; k < len(a) && k < len(b); k++ {
+		if a[k] != b[k] {
+			return a[k] < b[k]
+		}
+	}
+	return len(a) < len(b)
+}
@@ -0,0 +1,36 @@
+package canonicaljson
+
+import "testing"
+
+// examples of RFC 8785
+func TestTransform(t *testing.T) {
+	tests := []struct{ name, in, out string }{
+		{"numbers", `[333333333.33333329, 1E30, 4.50, 2e-3, 0.000000000000000000000000001, -0, 1e21, 1e-7]`,
+			`[333333333.3333333,1e+30,4.5,0.002,1e-27,0,1e+21,1e-7]`},
+		{"strings", `{"string": "\u20ac$\u000F\u000aA'\u0042\u0022\u005c\\\"\/", "literals": [null, true, false]}`,
+			`{"literals":[null,true,false],"string":"€$\u000f\nA'B\"\\\\\"/"}`},
+		{"sorting", `{"\u20ac": 1, "\r": 2, "\ufb33": 3, "1": 4, "\ud83d\ude00": 5, "\u0080": 6, "\u00f6": 7}`,
+			"{\"\\r\":2,\"1\":4,\"\u0080\":6,\"ö\":7,\"€\":1,\"😀\":5,\"\ufb33\":3}"},
+		{"nested", `{"b": [{"d": 1, "c": 2}], "a": {}}`, `{"a":{},"b":[{"c":2,"d":1}]}`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			out, err := Transform([]byte(test.in))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(out) != test.out {
+				t.Errorf("expected %s, got %s", test.out, out)
+			}
+		})
+	}
+}
+
+func TestTransformInvalid(t *testing.T) {
+	for _, in := range []string{`{"a":`, `{"a":1} {}`, `[1,]`} {
+		if _, err := Transform([]byte(in)); err == nil {
+			t.Errorf("expected an error for %s", in)
+		}
+	}
+}
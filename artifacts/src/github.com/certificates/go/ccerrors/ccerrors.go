@@ -0,0 +1,115 @@
+// Package ccerrors contains the error model of the certificates chaincode.
+//
+// Every error returned by the chaincode is a JSON payload with a stable code
+// and a human readable message:
+//
+//	{"code":"NOT_FOUND","message":"Badge doesn't exist"}
+//
+// Invalid arguments also list every invalid field, the message joins them:
+//
+//	{"code":"INVALID_ARGUMENT",
+//	 "message":"issuerUrl must be an absolute http(s) URL; badgeName must not be empty",
+//	 "details":[
+//	  {"field":"issuerUrl","message":"must be an absolute http(s) URL"},
+//	  {"field":"badgeName","message":"must not be empty"}]}
+//
+// Gateways can use Parse and HTTPStatus to map them to HTTP responses.
+package ccerrors
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// Code of an error, stable across chaincode versions
+type Code string
+
+const (
+	NotFound        Code = "NOT_FOUND"
+	AlreadyExists   Code = "ALREADY_EXISTS"
+	Forbidden       Code = "FORBIDDEN"
+	InvalidArgument Code = "INVALID_ARGUMENT"
+	Conflict        Code = "CONFLICT"
+	DataCorrupted   Code = "DATA_CORRUPTED" // state can't be decoded
+	Internal        Code = "INTERNAL"
+)
+
+// Error is a chaincode error with a code. Details lists the invalid fields
+// of an INVALID_ARGUMENT error (see package validation).
+type Error struct {
+	Code    Code     `json:"code"`
+	Message string   `json:"message"`
+	Details []Detail `json:"details,omitempty"`
+}
+
+// Detail is a violation on a single field
+type Detail struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// New creates an Error
+func New(code Code, message string) *Error {
+	return &Error{Code: code, Message: message}
+}
+
+func (e *Error) Error() string {
+	return string(e.Code) + ": " + e.Message
+}
+
+// JSON returns the error payload sent to clients
+func (e *Error) JSON() string {
+	out, err := json.Marshal(e)
+	if err != nil {
+		// can't happen with string fields
+		return `{"code":"` + string(Internal) + `","message":"error marshaling error"}`
+	}
+	return string(out)
+}
+
+// From returns err as an *Error. Errors without code are INTERNAL.
+func From(err error) *Error {
+	if err == nil {
+		return nil
+	}
+	if e, ok := err.(*Error); ok {
+		return e
+	}
+	return New(Internal, err.Error())
+}
+
+// CodeOf returns the code of err (INTERNAL for errors without code, empty
+// for nil)
+func CodeOf(err error) Code {
+	if err == nil {
+		return ""
+	}
+	return From(err).Code
+}
+
+// Parse decodes an error payload returned by the chaincode. ok is false if
+// the message is not a chaincode error payload.
+func Parse(message string) (e *Error, ok bool) {
+	e = &Error{}
+	err := json.Unmarshal([]byte(message), e)
+	if err != nil || e.Code == "" {
+		return nil, false
+	}
+	return e, true
+}
+
+// HTTPStatus maps an error code to an HTTP status
+func HTTPStatus(code Code) int {
+	switch code {
+	case NotFound:
+		return http.StatusNotFound
+	case AlreadyExists, Conflict:
+		return http.StatusConflict
+	case Forbidden:
+		return http.StatusForbidden
+	case InvalidArgument:
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,73 @@
+package ccerrors
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+// TestRoundTrip sends every code through the JSON payload and back
+func TestRoundTrip(t *testing.T) {
+	for _, c := range []struct {
+		code   Code
+		status int
+	}{
+		{NotFound, http.StatusNotFound},
+		{AlreadyExists, http.StatusConflict},
+		{Forbidden, http.StatusForbidden},
+		{InvalidArgument, http.StatusBadRequest},
+		{Conflict, http.StatusConflict},
+		{DataCorrupted, http.StatusInternalServerError},
+		{Internal, http.StatusInternalServerError},
+	} {
+		t.Run(string(c.code), func(t *testing.T) {
+			e := New(c.code, "message with \"quotes\"")
+			if c.code == InvalidArgument {
+				e.Details = []Detail{{Field: "badgeName", Message: "must not be empty"}}
+			}
+
+			parsed, ok := Parse(e.JSON())
+			if !ok {
+				t.Fatalf("expected %s to parse", e.JSON())
+			}
+			if !reflect.DeepEqual(parsed, e) {
+				t.Errorf("expected %v, got %v", e, parsed)
+			}
+			if CodeOf(parsed) != c.code {
+				t.Errorf("expected code %s, got %s", c.code, CodeOf(parsed))
+			}
+			if status := HTTPStatus(c.code); status != c.status {
+				t.Errorf("expected status %d, got %d", c.status, status)
+			}
+		})
+	}
+}
+
+func TestFrom(t *testing.T) {
+	e := New(NotFound, "Badge doesn't exist")
+	if From(e) != e {
+		t.Errorf("expected the same error, got %v", From(e))
+	}
+	if from := From(errors.New("boom")); from.Code != Internal || from.Message != "boom" {
+		t.Errorf("expected an INTERNAL error, got %v", from)
+	}
+	if From(nil) != nil || CodeOf(nil) != "" {
+		t.Error("expected no error for nil")
+	}
+	if e.Error() != "NOT_FOUND: Badge doesn't exist" {
+		t.Errorf("unexpected message %q", e.Error())
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	for name, message := range map[string]string{
+		"plain text": "transaction returned with failure: boom",
+		"not JSON":   `{"code":`,
+		"no code":    `{"message":"boom"}`,
+	} {
+		if e, ok := Parse(message); ok {
+			t.Errorf("%s: expected no error payload, got %v", name, e)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+// Package cctest is a harness to run the certificates chaincode on a mock
+// stub, outside of a Fabric network.
+//
+// It generates synthetic client identities (see package mspidentity):
+// X.509 certificates carrying Fabric CA attributes (role, email...), wrapped
+// in serialized MSP identities, so cid sees the same creator as on a peer:
+//
+//	ca, _ := cctest.NewCA("Org1MSP")
+//	issuer, _ := ca.NewIdentity("issuer1", map[string]string{
+//		"role": "university", "email": "issuer1@org1.example.com"})
+//
+//	stub := cctest.NewStub("certificates", new(SimpleChaincode))
+//	resp := stub.As(issuer).Invoke("initLedger")
+package cctest
+
+import (
+	"time"
+
+	"github.com/certificates/go/mspidentity"
+)
+
+// validity of the generated CAs and identities
+const validity = 365 * 24 * time.Hour
+
+// CA is a throwaway certificate authority of an MSP
+type CA struct {
+	*mspidentity.CA
+}
+
+// Identity is a client identity issued by a CA
+type Identity = mspidentity.Identity
+
+// NewCA creates a self-signed CA for mspID
+func NewCA(mspID string) (*CA, error) {
+	ca, err := mspidentity.NewCA(mspID, validity)
+	if err != nil {
+		return nil, err
+	}
+	return &CA{ca}, nil
+}
+
+// NewIdentity issues a client certificate for commonName with the given
+// Fabric CA attributes
+func (ca *CA) NewIdentity(commonName string, attrs map[string]string) (*Identity, error) {
+	return ca.CA.NewIdentity(commonName, attrs, validity)
+}
+
+// NewUniversity issues an identity with the attributes the chaincode
+// expects from issuers (role=university and email)
+func (ca *CA) NewUniversity(email string) (*Identity, error) {
+	return ca.NewIdentity(email, map[string]string{"role": "university", "email": email})
+}
+
+// IdentityFromCert wraps an existing certificate (e.g. the signcerts of an
+// MSP folder) in an Identity. The private key is not needed by the stub.
+func IdentityFromCert(mspID string, certPEM []byte) (*Identity, error) {
+	return mspidentity.IdentityFromCert(mspID, certPEM)
+}
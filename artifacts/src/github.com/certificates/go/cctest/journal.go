@@ -0,0 +1,137 @@
+package cctest
+
+import (
+	"errors"
+	"sort"
+)
+
+// Writes are applied to the state maps right away and journaled, and the
+// journal is undone when a transaction fails or is a query. Unlike the
+// MockStub, a write doesn't insert its key in the sorted Keys list (O(n)):
+// the list is sorted again before the next range query.
+
+// undo restores a key of the world state (collection ""), of a private data
+// collection or of the endorsement policies
+type undo struct {
+	policy     bool
+	collection string
+	key        string
+	value      []byte
+	existed    bool
+}
+
+func (s *Stub) PutState(key string, value []byte) error {
+	if s.TxID == "" {
+		return errors.New("cannot PutState without a transaction")
+	}
+	// as on a peer, an empty value deletes the key
+	if len(value) == 0 {
+		return s.DelState(key)
+	}
+	if err := s.checkWrite(); err != nil {
+		return err
+	}
+
+	s.recordWrite("", key, value)
+	previous, existed := s.State[key]
+	s.journal = append(s.journal, undo{key: key, value: previous, existed: existed})
+	s.State[key] = value
+	if !existed {
+		s.keysDirty = true
+	}
+	return nil
+}
+
+func (s *Stub) DelState(key string) error {
+	if err := s.checkWrite(); err != nil {
+		return err
+	}
+	s.recordWrite("", key, nil)
+	previous, existed := s.State[key]
+	if !existed {
+		return nil
+	}
+	s.journal = append(s.journal, undo{key: key, value: previous, existed: true})
+	delete(s.State, key)
+	s.keysDirty = true
+	return nil
+}
+
+func (s *Stub) PutPrivateData(collection, key string, value []byte) error {
+	if err := s.checkWrite(); err != nil {
+		return err
+	}
+	s.recordWrite(collection, key, value)
+	m, ok := s.PvtState[collection]
+	if !ok {
+		m = make(map[string][]byte)
+		s.PvtState[collection] = m
+	}
+	previous, existed := m[key]
+	s.journal = append(s.journal, undo{collection: collection, key: key, value: previous, existed: existed})
+	m[key] = value
+	return nil
+}
+
+func (s *Stub) DelPrivateData(collection, key string) error {
+	if err := s.checkWrite(); err != nil {
+		return err
+	}
+	s.recordWrite(collection, key, nil)
+	previous, existed := s.PvtState[collection][key]
+	if !existed {
+		return nil
+	}
+	s.journal = append(s.journal, undo{collection: collection, key: key, value: previous, existed: true})
+	delete(s.PvtState[collection], key)
+	return nil
+}
+
+func (s *Stub) SetStateValidationParameter(key string, ep []byte) error {
+	if err := s.checkWrite(); err != nil {
+		return err
+	}
+	previous, existed := s.EndorsementPolicies[""][key]
+	s.journal = append(s.journal, undo{policy: true, key: key, value: previous, existed: existed})
+	return s.MockStub.SetStateValidationParameter(key, ep)
+}
+
+// rollback undoes the writes of the current transaction
+func (s *Stub) rollback() {
+	for i := len(s.journal) - 1; i >= 0; i-- {
+		u := s.journal[i]
+		var m map[string][]byte
+		switch {
+		case u.policy:
+			m = s.EndorsementPolicies[""]
+		case u.collection != "":
+			m = s.PvtState[u.collection]
+		default:
+			m = s.State
+			s.keysDirty = true
+		}
+		if u.existed {
+			m[u.key] = u.value
+		} else {
+			delete(m, u.key)
+		}
+	}
+	s.journal = s.journal[:0]
+}
+
+// sortKeys rebuilds the sorted Keys list of the MockStub range queries
+func (s *Stub) sortKeys() {
+	if !s.keysDirty {
+		return
+	}
+	keys := make([]string, 0, len(s.State))
+	for key := range s.State {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	s.Keys.Init()
+	for _, key := range keys {
+		s.Keys.PushBack(key)
+	}
+	s.keysDirty = false
+}
@@ -0,0 +1,78 @@
+package cctest
+
+import (
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+// RWSet is the read/write set of a transaction, in call order. Collection
+// is empty for world state.
+type RWSet struct {
+	Reads      []Read      `json:"reads"`
+	RangeReads []RangeRead `json:"rangeReads"`
+	Writes     []Write     `json:"writes"`
+}
+
+type Read struct {
+	Collection string `json:"collection,omitempty"`
+	Key        string `json:"key"`
+}
+
+type RangeRead struct {
+	Collection string `json:"collection,omitempty"`
+	StartKey   string `json:"startKey"`
+	EndKey     string `json:"endKey"`
+}
+
+type Write struct {
+	Collection string `json:"collection,omitempty"`
+	Key        string `json:"key"`
+	Value      []byte `json:"value,omitempty"`
+	Delete     bool   `json:"delete,omitempty"`
+}
+
+func newRWSet() *RWSet {
+	return &RWSet{Reads: []Read{}, RangeReads: []RangeRead{}, Writes: []Write{}}
+}
+
+func (s *Stub) recordRead(collection, key string) {
+	s.RWSet.Reads = append(s.RWSet.Reads, Read{Collection: collection, Key: key})
+}
+
+func (s *Stub) recordRangeRead(collection, startKey, endKey string) {
+	s.RWSet.RangeReads = append(s.RWSet.RangeReads, RangeRead{Collection: collection, StartKey: startKey, EndKey: endKey})
+}
+
+func (s *Stub) recordWrite(collection, key string, value []byte) {
+	s.RWSet.Writes = append(s.RWSet.Writes, Write{Collection: collection, Key: key, Value: value, Delete: value == nil})
+}
+
+// Recording overrides
+
+func (s *Stub) GetState(key string) ([]byte, error) {
+	s.recordRead("", key)
+	return s.MockStub.GetState(key)
+}
+
+func (s *Stub) GetStateByRange(startKey, endKey string) (shim.StateQueryIteratorInterface, error) {
+	s.recordRangeRead("", startKey, endKey)
+	s.sortKeys()
+	return s.MockStub.GetStateByRange(startKey, endKey)
+}
+
+func (s *Stub) GetStateByPartialCompositeKey(objectType string, keys []string) (shim.StateQueryIteratorInterface, error) {
+	startKey, err := s.CreateCompositeKey(objectType, keys)
+	if err != nil {
+		return nil, err
+	}
+	s.recordRangeRead("", startKey, startKey+maxUnicodeRune)
+	s.sortKeys()
+	return s.MockStub.GetStateByPartialCompositeKey(objectType, keys)
+}
+
+func (s *Stub) GetPrivateData(collection, key string) ([]byte, error) {
+	s.recordRead(collection, key)
+	return s.MockStub.GetPrivateData(collection, key)
+}
+
+// compile time check
+var _ shim.ChaincodeStubInterface = (*Stub)(nil)
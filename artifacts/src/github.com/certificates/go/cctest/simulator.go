@@ -0,0 +1,62 @@
+package cctest
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+// Like the transaction simulator of a Fabric 1.4 peer, a transaction can't
+// write after a query on private data or a paginated query, and can't run
+// these queries after a write: their results are not validated at commit,
+// so they are only allowed in read-only transactions. Writes include
+// private data and endorsement policies.
+
+// simulator tracks the operations run by the current transaction
+type simulator struct {
+	written          bool
+	pvtQueried       bool
+	paginatedQueried bool
+}
+
+func (s *Stub) checkWrite() error {
+	if s.sim.pvtQueried {
+		return fmt.Errorf("txid [%s]: Transaction has already performed queries on pvt data. Writes are not allowed", s.TxID)
+	}
+	if s.sim.paginatedQueried {
+		return fmt.Errorf("txid [%s]: Transaction has already performed a paginated query. Writes are not allowed", s.TxID)
+	}
+	s.sim.written = true
+	return nil
+}
+
+func (s *Stub) checkPvtQuery() error {
+	if s.sim.written {
+		return fmt.Errorf("txid [%s]: Queries on pvt data is supported only in a read-only transaction", s.TxID)
+	}
+	s.sim.pvtQueried = true
+	return nil
+}
+
+func (s *Stub) checkPaginatedQuery() error {
+	if s.sim.written {
+		return fmt.Errorf("txid [%s]: Paginated queries are supported only in a read-only transaction", s.TxID)
+	}
+	s.sim.paginatedQueried = true
+	return nil
+}
+
+func (s *Stub) GetPrivateDataByPartialCompositeKey(collection, objectType string, keys []string) (shim.StateQueryIteratorInterface, error) {
+	startKey, err := s.CreateCompositeKey(objectType, keys)
+	if err != nil {
+		return nil, err
+	}
+	return s.GetPrivateDataByRange(collection, startKey, startKey+maxUnicodeRune)
+}
+
+func (s *Stub) GetPrivateDataQueryResult(collection, query string) (shim.StateQueryIteratorInterface, error) {
+	if err := s.checkPvtQuery(); err != nil {
+		return nil, err
+	}
+	return s.GetQueryResult(query)
+}
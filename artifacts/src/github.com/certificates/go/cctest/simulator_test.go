@@ -0,0 +1,66 @@
+package cctest
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	pb "github.com/hyperledger/fabric/protos/peer"
+)
+
+// chaincode running the operations named by its arguments, in order
+type opsChaincode struct{}
+
+func (opsChaincode) Init(stub shim.ChaincodeStubInterface) pb.Response {
+	return shim.Success(nil)
+}
+
+func (opsChaincode) Invoke(stub shim.ChaincodeStubInterface) pb.Response {
+	for _, op := range stub.GetStringArgs() {
+		var err error
+		switch op {
+		case "put":
+			err = stub.PutState("key", []byte("value"))
+		case "del":
+			err = stub.DelState("key")
+		case "putPrivate":
+			err = stub.PutPrivateData("collection", "key", []byte("value"))
+		case "policy":
+			err = stub.SetStateValidationParameter("key", []byte("policy"))
+		case "range":
+			_, err = stub.GetStateByRange("a", "z")
+		case "rangePrivate":
+			_, err = stub.GetPrivateDataByRange("collection", "a", "z")
+		case "rangePaginated":
+			_, _, err = stub.GetStateByRangeWithPagination("a", "z", 10, "")
+		}
+		if err != nil {
+			return shim.Error(err.Error())
+		}
+	}
+	return shim.Success(nil)
+}
+
+func TestSimulatorRestrictions(t *testing.T) {
+	tests := []struct {
+		ops []string
+		ok  bool
+	}{
+		{[]string{"range", "put", "del"}, true},
+		{[]string{"put", "range"}, true},
+		{[]string{"rangePrivate", "rangePaginated"}, true},
+		{[]string{"rangePrivate", "put"}, false},
+		{[]string{"rangePrivate", "putPrivate"}, false},
+		{[]string{"rangePaginated", "del"}, false},
+		{[]string{"rangePaginated", "policy"}, false},
+		{[]string{"put", "rangePrivate"}, false},
+		{[]string{"policy", "rangePaginated"}, false},
+	}
+
+	stub := NewStub("ops", opsChaincode{})
+	for _, test := range tests {
+		resp := stub.Invoke(test.ops[0], test.ops[1:]...)
+		if ok := resp.Status < shim.ERRORTHRESHOLD; ok != test.ok {
+			t.Errorf("%q: expected success %t, got %d %s", test.ops, test.ok, resp.Status, resp.Message)
+		}
+	}
+}
@@ -0,0 +1,290 @@
+package cctest
+
+import (
+	"errors"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	"github.com/hyperledger/fabric/protos/ledger/queryresult"
+	pb "github.com/hyperledger/fabric/protos/peer"
+)
+
+const (
+	maxUnicodeRune = "\U0010FFFF"
+	// start of simple keys, composite keys start with U+0000
+	emptyKeySubstitute = "\x01"
+)
+
+// DefaultClock is the timestamp of the first transaction of a Stub
+var DefaultClock = time.Date(2018, time.October, 17, 7, 29, 47, 0, time.UTC)
+
+// Stub is a shim.MockStub that also supports what the chaincode needs from
+// a peer: creator identity, transient map, private data ranges, paginated
+// range queries and a deterministic transaction clock.
+//
+// Like a peer, writes of a transaction that returns an error are discarded,
+// and writes can't be mixed with private data or paginated queries (see
+// simulator.go). Writes are journaled (see journal.go), a transaction never copies the
+// whole state.
+type Stub struct {
+	*shim.MockStub
+
+	cc shim.Chaincode
+
+	// Identity is the creator of the next transactions
+	Identity *Identity
+	// Clock is the timestamp of the next transaction, advanced by Step
+	// after each transaction
+	Clock time.Time
+	Step  time.Duration
+	// Event is the event set by the last transaction (nil if none)
+	Event *pb.ChaincodeEvent
+	// RWSet is the read/write set of the last transaction
+	RWSet *RWSet
+	// TxCount is the number of transactions run, transaction IDs are
+	// tx<TxCount>
+	TxCount int
+	// LastTxID is the ID of the last transaction (TxID is cleared when a
+	// transaction ends)
+	LastTxID string
+
+	args      [][]byte
+	transient map[string][]byte
+	journal   []undo
+	sim       simulator
+	// Keys is sorted lazily, before range queries (see journal.go)
+	keysDirty bool
+}
+
+// NewStub creates a Stub running cc
+func NewStub(name string, cc shim.Chaincode) *Stub {
+	return &Stub{
+		MockStub: shim.NewMockStub(name, cc),
+		cc:       cc,
+		Clock:    DefaultClock,
+		Step:     time.Second,
+		RWSet:    newRWSet(),
+	}
+}
+
+// As sets the creator of the next transactions
+func (s *Stub) As(identity *Identity) *Stub {
+	s.Identity = identity
+	return s
+}
+
+// Invoke runs a transaction
+func (s *Stub) Invoke(function string, args ...string) pb.Response {
+	return s.InvokeWithTransient(nil, function, args...)
+}
+
+// InvokeWithTransient runs a transaction with a transient map
+func (s *Stub) InvokeWithTransient(transient map[string][]byte, function string, args ...string) pb.Response {
+	return s.run(true, transient, function, args)
+}
+
+// Query runs a transaction and discards its writes, like a proposal that is
+// evaluated but not submitted for ordering
+func (s *Stub) Query(function string, args ...string) pb.Response {
+	return s.QueryWithTransient(nil, function, args...)
+}
+
+// QueryWithTransient runs a query with a transient map
+func (s *Stub) QueryWithTransient(transient map[string][]byte, function string, args ...string) pb.Response {
+	return s.run(false, transient, function, args)
+}
+
+func (s *Stub) run(commit bool, transient map[string][]byte, function string, args []string) pb.Response {
+	s.TxCount++
+	txID := "tx" + strconv.Itoa(s.TxCount)
+	s.LastTxID = txID
+
+	s.args = [][]byte{[]byte(function)}
+	for _, arg := range args {
+		s.args = append(s.args, []byte(arg))
+	}
+	s.transient = transient
+	s.Event = nil
+	s.RWSet = newRWSet()
+	s.journal = s.journal[:0]
+	s.sim = simulator{}
+
+	s.MockTransactionStart(txID)
+	s.TxTimestamp = &timestamp.Timestamp{Seconds: s.Clock.Unix(), Nanos: int32(s.Clock.Nanosecond())}
+	resp := s.cc.Invoke(s)
+	s.MockTransactionEnd(txID)
+
+	if resp.Status >= shim.ERRORTHRESHOLD {
+		s.rollback()
+		s.Event = nil
+	} else if !commit {
+		s.rollback()
+	}
+	s.Clock = s.Clock.Add(s.Step)
+
+	return resp
+}
+
+// ChaincodeStubInterface overrides
+
+func (s *Stub) GetArgs() [][]byte {
+	return s.args
+}
+
+func (s *Stub) GetStringArgs() []string {
+	strargs := make([]string, 0, len(s.args))
+	for _, arg := range s.args {
+		strargs = append(strargs, string(arg))
+	}
+	return strargs
+}
+
+func (s *Stub) GetFunctionAndParameters() (string, []string) {
+	allargs := s.GetStringArgs()
+	if len(allargs) == 0 {
+		return "", []string{}
+	}
+	return allargs[0], allargs[1:]
+}
+
+func (s *Stub) GetCreator() ([]byte, error) {
+	if s.Identity == nil {
+		return nil, errors.New("no creator identity, use Stub.As")
+	}
+	return s.Identity.Creator, nil
+}
+
+func (s *Stub) GetTransient() (map[string][]byte, error) {
+	if s.transient == nil {
+		return map[string][]byte{}, nil
+	}
+	return s.transient, nil
+}
+
+func (s *Stub) SetEvent(name string, payload []byte) error {
+	if name == "" {
+		return errors.New("event name can not be nil string")
+	}
+	s.Event = &pb.ChaincodeEvent{TxId: s.TxID, EventName: name, Payload: payload}
+	return nil
+}
+
+func (s *Stub) GetQueryResult(query string) (shim.StateQueryIteratorInterface, error) {
+	return nil, errors.New("rich queries need CouchDB and are not supported by the mock stub")
+}
+
+func (s *Stub) GetQueryResultWithPagination(query string, pageSize int32, bookmark string) (shim.StateQueryIteratorInterface, *pb.QueryResponseMetadata, error) {
+	if err := s.checkPaginatedQuery(); err != nil {
+		return nil, nil, err
+	}
+	return nil, nil, errors.New("rich queries need CouchDB and are not supported by the mock stub")
+}
+
+// GetStateByRangeWithPagination pages over the sorted keys. As on a peer,
+// the bookmark is the first key of the next page, and an empty start key
+// excludes composite keys.
+func (s *Stub) GetStateByRangeWithPagination(startKey, endKey string, pageSize int32, bookmark string) (shim.StateQueryIteratorInterface, *pb.QueryResponseMetadata, error) {
+	if err := s.checkPaginatedQuery(); err != nil {
+		return nil, nil, err
+	}
+	s.recordRangeRead("", startKey, endKey)
+	s.sortKeys()
+	if startKey == "" {
+		startKey = emptyKeySubstitute
+	}
+	if bookmark != "" {
+		startKey = bookmark
+	}
+
+	var records []*queryresult.KV
+	nextKey := ""
+	for elem := s.Keys.Front(); elem != nil; elem = elem.Next() {
+		key := elem.Value.(string)
+		if key < startKey || (endKey != "" && key >= endKey) {
+			continue
+		}
+		if int32(len(records)) == pageSize {
+			nextKey = key
+			break
+		}
+		records = append(records, &queryresult.KV{Key: key, Value: s.State[key]})
+	}
+
+	metadata := &pb.QueryResponseMetadata{FetchedRecordsCount: int32(len(records)), Bookmark: nextKey}
+	return &iterator{records: records}, metadata, nil
+}
+
+func (s *Stub) GetStateByPartialCompositeKeyWithPagination(objectType string, keys []string, pageSize int32, bookmark string) (shim.StateQueryIteratorInterface, *pb.QueryResponseMetadata, error) {
+	startKey, err := s.CreateCompositeKey(objectType, keys)
+	if err != nil {
+		return nil, nil, err
+	}
+	return s.GetStateByRangeWithPagination(startKey, startKey+maxUnicodeRune, pageSize, bookmark)
+}
+
+func (s *Stub) GetPrivateDataByRange(collection, startKey, endKey string) (shim.StateQueryIteratorInterface, error) {
+	if err := s.checkPvtQuery(); err != nil {
+		return nil, err
+	}
+	s.recordRangeRead(collection, startKey, endKey)
+	m := s.PvtState[collection]
+
+	var keys []string
+	for key := range m {
+		if key >= startKey && (endKey == "" || key < endKey) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	records := make([]*queryresult.KV, len(keys))
+	for i, key := range keys {
+		records[i] = &queryresult.KV{Key: key, Value: m[key]}
+	}
+	return &iterator{records: records}, nil
+}
+
+// Load replaces the world state and private data
+func (s *Stub) Load(state map[string][]byte, private map[string]map[string][]byte) {
+	s.State = copyMap(state)
+	s.keysDirty = true
+	s.sortKeys()
+
+	s.PvtState = make(map[string]map[string][]byte)
+	for collection, m := range private {
+		s.PvtState[collection] = copyMap(m)
+	}
+}
+
+func copyMap(m map[string][]byte) map[string][]byte {
+	c := make(map[string][]byte, len(m))
+	for k, v := range m {
+		c[k] = v
+	}
+	return c
+}
+
+// iterator over a slice of records
+type iterator struct {
+	records []*queryresult.KV
+	next    int
+}
+
+func (it *iterator) HasNext() bool {
+	return it.next < len(it.records)
+}
+
+func (it *iterator) Next() (*queryresult.KV, error) {
+	if !it.HasNext() {
+		return nil, errors.New("no more records")
+	}
+	it.next++
+	return it.records[it.next-1], nil
+}
+
+func (it *iterator) Close() error {
+	return nil
+}
@@ -0,0 +1,94 @@
+package cctest
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/certificates/go/ccerrors"
+	pb "github.com/hyperledger/fabric/protos/peer"
+)
+
+// Case is a transaction and its expected outcome
+type Case struct {
+	Name string
+	// Setup is optionally run on the stub before the transaction, e.g. to
+	// write raw state
+	Setup     func(s *Stub)
+	Identity  *Identity
+	Transient map[string][]byte
+	Function  string
+	Args      []string
+	// Code is the expected error code, empty if the transaction must succeed
+	Code ccerrors.Code
+	// Check is an optional check of a successful payload
+	Check func(payload []byte) error
+}
+
+// Run runs cases in order on the same stub, each as a subtest of t (later
+// cases see the state written by earlier ones)
+func (s *Stub) Run(t *testing.T, cases []Case) {
+	t.Helper()
+	for _, c := range cases {
+		c := c
+		t.Run(c.Name, func(t *testing.T) {
+			if c.Setup != nil {
+				c.Setup(s)
+			}
+			s.As(c.Identity)
+			resp := s.InvokeWithTransient(c.Transient, c.Function, c.Args...)
+			if reason := checkResponse(c, resp); reason != "" {
+				t.Error(reason)
+			}
+		})
+	}
+}
+
+func checkResponse(c Case, resp pb.Response) string {
+	if c.Code != "" {
+		if resp.Status < 400 {
+			return fmt.Sprintf("expected %s, transaction succeeded", c.Code)
+		}
+		e, ok := ccerrors.Parse(resp.Message)
+		if !ok {
+			return "expected " + string(c.Code) + ", got untyped error: " + resp.Message
+		}
+		if e.Code != c.Code {
+			return "expected " + string(c.Code) + ", got " + e.JSON()
+		}
+		return ""
+	}
+
+	if resp.Status >= 400 {
+		return "expected success, got: " + resp.Message
+	}
+	if c.Check != nil {
+		if err := c.Check(resp.Payload); err != nil {
+			return err.Error()
+		}
+	}
+	return ""
+}
+
+// Transient builds a transient map entry from a JSON value
+func Transient(key string, value interface{}) map[string][]byte {
+	out, err := json.Marshal(value)
+	if err != nil {
+		panic(err)
+	}
+	return map[string][]byte{key: out}
+}
+
+// Decode is a Check helper that decodes a payload into v (a pointer, reset
+// first) and runs check
+func Decode(v interface{}, check func() error) func(payload []byte) error {
+	return func(payload []byte) error {
+		elem := reflect.ValueOf(v).Elem()
+		elem.Set(reflect.Zero(elem.Type()))
+		if err := json.Unmarshal(payload, v); err != nil {
+			return fmt.Errorf("invalid payload %s: %s", payload, err)
+		}
+		return check()
+	}
+}
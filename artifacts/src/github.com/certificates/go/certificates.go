@@ -1,12 +1,26 @@
 package main
 
 import (
+	"strings"
+
+	"github.com/certificates/go/ccerrors"
 	"github.com/hyperledger/fabric/core/chaincode/shim"
 	pb "github.com/hyperledger/fabric/protos/peer"
 )
 
 var logger = shim.NewLogger("certificates")
 
+// functions accepted by Invoke
+var functionNames = []string{
+	"initLedger", "issueBadge", "issueCertificate", "forgetRecipient",
+	"verifyRecipientIdentity", "queryCertificates", "queryBadges",
+	"listCertificates", "listBadges", "searchBadges", "getIssuerStats",
+	"getBadgeStats", "verifyCertificate", "verifyDocumentHash", "getCertificate",
+	"exportState", "importState", "getCertificateDigest", "migrateCertificates",
+	"listIssuerCertificates", "listIssuerBadges", "setRecipientPepper",
+	"revokeCertificate", "renewRecipientData",
+}
+
 // SimpleChaincode example simple Chaincode implementation
 type SimpleChaincode struct {
 }
@@ -21,6 +35,22 @@ func (t *SimpleChaincode) Init(stub shim.ChaincodeStubInterface) pb.Response {
 func (t *SimpleChaincode) Invoke(stub shim.ChaincodeStubInterface) pb.Response {
 	logger.Info("########### certificates Invoke ###########")
 
+	// Reads see the writes of the transaction, which are flushed once it
+	// succeeds (see utils_txstate.go)
+	state := newTxState(stub)
+	resp := t.invoke(state)
+	if resp.Status >= shim.ERRORTHRESHOLD {
+		return resp
+	}
+
+	err := state.flush()
+	if err != nil {
+		return errorResponse(err)
+	}
+	return resp
+}
+
+func (t *SimpleChaincode) invoke(stub shim.ChaincodeStubInterface) pb.Response {
 	function, args := stub.GetFunctionAndParameters()
 
 	// check if user is an university
@@ -28,7 +58,7 @@ func (t *SimpleChaincode) Invoke(stub shim.ChaincodeStubInterface) pb.Response {
 
 	if err != nil {
 		// user doesn't have the expected role or attrs retrieval error
-		return shim.Error(err.Error())
+		return errorResponse(err)
 	}
 
 	// check if user has email
@@ -36,7 +66,7 @@ func (t *SimpleChaincode) Invoke(stub shim.ChaincodeStubInterface) pb.Response {
 
 	if attrErr != nil {
 		// error getting user's email
-		return shim.Error(attrErr.Error())
+		return errorResponse(attrErr)
 	}
 
 	if function == "initLedger" {
@@ -55,6 +85,72 @@ func (t *SimpleChaincode) Invoke(stub shim.ChaincodeStubInterface) pb.Response {
 		// Issue a certificate
 		return t.issueCertificate(stub, arguments)
 	}
+	if function == "revokeCertificate" {
+		// Add email to arguments at 1st position
+		arguments := append([]string{val}, args...)
+		// Revoke a certificate
+		return t.revokeCertificate(stub, arguments)
+	}
+	if function == "forgetRecipient" {
+		// Erase recipient personal data (recipient is read from transient map)
+		return t.forgetRecipient(stub, args)
+	}
+	if function == "renewRecipientData" {
+		// rewrite recipient private data before it expires (admins only)
+		return t.renewRecipientData(stub, args)
+	}
+	if function == "setRecipientPepper" {
+		// secret of the recipient indexes (admins only, pepper is read from transient map)
+		return t.setRecipientPepper(stub, args)
+	}
+	if function == "verifyRecipientIdentity" {
+		// check a recipient identity against the certificate hash
+		return t.verifyRecipientIdentity(stub, args)
+	}
+	if function == "queryCertificates" {
+		// rich query on certificates
+		return t.queryCertificates(stub, args)
+	}
+	if function == "queryBadges" {
+		// rich query on badges
+		return t.queryBadges(stub, args)
+	}
+	if function == "listCertificates" {
+		// list certificates by key
+		return t.listCertificates(stub, args)
+	}
+	if function == "listBadges" {
+		// list badges by key
+		return t.listBadges(stub, args)
+	}
+	if function == "listIssuerCertificates" {
+		// list the certificates of an issuer by key
+		return t.listIssuerCertificates(stub, args)
+	}
+	if function == "listIssuerBadges" {
+		// list the badges of an issuer by key
+		return t.listIssuerBadges(stub, args)
+	}
+	if function == "searchBadges" {
+		// search the badge catalogue
+		return t.searchBadges(stub, args)
+	}
+	if function == "getIssuerStats" {
+		// issuer statistics
+		return t.getIssuerStats(stub, args)
+	}
+	if function == "getBadgeStats" {
+		// badge statistics
+		return t.getBadgeStats(stub, args)
+	}
+	if function == "verifyCertificate" {
+		// structured verification verdict
+		return t.verifyCertificate(stub, args)
+	}
+	if function == "verifyDocumentHash" {
+		// certificates of an attached document
+		return t.verifyDocumentHash(stub, args)
+	}
 	if function == "getCertificate" {
 		// Add email to arguments at 1st position
 		// arguments := []string{val}
@@ -62,15 +158,23 @@ func (t *SimpleChaincode) Invoke(stub shim.ChaincodeStubInterface) pb.Response {
 		// get a certificate
 		return t.getCertificate(stub, args)
 	}
-
-	errorMsg := "Unknown action, check the first argument, must be one of 'setCertificate' or 'getCertificate'. But got:" + args[0]
-	logger.Errorf(errorMsg)
-	return shim.Error(errorMsg)
-}
-
-func main() {
-	err := shim.Start(new(SimpleChaincode))
-	if err != nil {
-		logger.Errorf("Error starting Simple chaincode: %s", err)
+	if function == "getCertificateDigest" {
+		// canonical form and digest of a certificate
+		return t.getCertificateDigest(stub, args)
 	}
+	if function == "exportState" {
+		// page of the world state (admins only)
+		return t.exportState(stub, args)
+	}
+	if function == "importState" {
+		// replay an exported batch (admins only)
+		return t.importState(stub, args)
+	}
+	if function == "migrateCertificates" {
+		// badge references in legacy certificates (admins only)
+		return t.migrateCertificates(stub, args)
+	}
+
+	errorMsg := "Unknown function '" + function + "', must be one of: " + strings.Join(functionNames, ", ")
+	return errorResponse(ccerrors.New(ccerrors.InvalidArgument, errorMsg))
 }
@@ -0,0 +1,18 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/certificates/go/ccerrors"
+	"github.com/certificates/go/cctest"
+)
+
+func TestInvoke(t *testing.T) {
+	f := newLedger(t)
+
+	f.stub.Run(t, []cctest.Case{
+		{Name: "not a university", Identity: f.student, Function: "initLedger", Code: ccerrors.Forbidden},
+		{Name: "no email attribute", Identity: f.noEmail, Function: "initLedger", Code: ccerrors.Forbidden},
+		{Name: "unknown function", Identity: f.issuer1, Function: "nope", Code: ccerrors.InvalidArgument},
+	})
+}
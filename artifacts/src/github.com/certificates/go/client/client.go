@@ -0,0 +1,452 @@
+// Package client is a typed Go client of the certificates chaincode.
+//
+// It builds the positional arguments and transient data of each function
+// from request structs, and decodes the JSON responses, so callers never
+// assemble string arrays by hand:
+//
+//	c := client.New(transport)
+//	res, err := c.IssueBadge(client.IssueBadgeRequest{
+//		IssuerName: "Org1 University", IssuerURL: "https://org1.example.com",
+//		Name: "Data Science", ...})
+//
+// Calls go through a Transport, e.g. a Fabric SDK gateway. Chaincode errors
+// are returned as *ccerrors.Error.
+//
+// The response types mirror the JSON of the chaincode types (package main
+// of the chaincode, which can't be imported). The chaincode tests check
+// that they have the same JSON fields.
+package client
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strconv"
+
+	"github.com/certificates/go/ccerrors"
+)
+
+// transient map keys of the recipient private details and of the org
+// pepper
+const (
+	recipientTransientKey = "recipient"
+	pepperTransientKey    = "pepper"
+)
+
+// Transport sends chaincode proposals
+type Transport interface {
+	// Submit runs a transaction and commits it to the ledger
+	Submit(function string, args []string, transient map[string][]byte) ([]byte, error)
+	// Evaluate runs a query, its writes are discarded
+	Evaluate(function string, args []string, transient map[string][]byte) ([]byte, error)
+}
+
+// Client calls the certificates chaincode through a Transport
+type Client struct {
+	transport Transport
+}
+
+// New creates a Client
+func New(transport Transport) *Client {
+	return &Client{transport: transport}
+}
+
+// InitLedger creates the empty issuer list
+func (c *Client) InitLedger() (*Mutation, error) {
+	var res Mutation
+	err := c.submit(&res, "initLedger", nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// IssueBadge creates a badge of the caller
+func (c *Client) IssueBadge(req IssueBadgeRequest) (*BadgeResult, error) {
+	var res BadgeResult
+	err := c.submit(&res, "issueBadge", req.args(), nil)
+	if err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// SetRecipientPepper sets the secret that keys the recipient indexes of
+// the caller's organization (admins only). It is set once, before the
+// first certificate is issued, and must be random, e.g. NewSecret().
+func (c *Client) SetRecipientPepper(pepper string) (*Mutation, error) {
+	var res Mutation
+	err := c.submit(&res, "setRecipientPepper", nil, map[string][]byte{pepperTransientKey: []byte(pepper)})
+	if err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// IssueCertificate issues a certificate of one of the caller's badges. A
+// random salt is generated when the recipient has none.
+func (c *Client) IssueCertificate(req IssueCertificateRequest) (*CertificateResult, error) {
+	if req.Recipient.Salt == "" {
+		salt, err := NewSecret()
+		if err != nil {
+			return nil, err
+		}
+		req.Recipient.Salt = salt
+	}
+
+	transient, err := recipientTransient(req.Recipient)
+	if err != nil {
+		return nil, err
+	}
+
+	var res CertificateResult
+	err = c.submit(&res, "issueCertificate", req.args(), transient)
+	if err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// RevokeCertificate revokes a certificate of one of the caller's badges
+func (c *Client) RevokeCertificate(certID, reason string) (*CertificateResult, error) {
+	var res CertificateResult
+	err := c.submit(&res, "revokeCertificate", []string{certID, reason}, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// ForgetRecipient erases the private data of a recipient (matched by email)
+// in the caller's organization and flags their certificates (admins only)
+func (c *Client) ForgetRecipient(recipient RecipientDetails) (*Mutation, error) {
+	transient, err := recipientTransient(recipient)
+	if err != nil {
+		return nil, err
+	}
+
+	var res Mutation
+	err = c.submit(&res, "forgetRecipient", nil, transient)
+	if err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// GetCertificate returns a certificate, with the recipient profile when the
+// caller's organization issued it
+func (c *Client) GetCertificate(certID string) (*Certificate, error) {
+	var cert Certificate
+	err := c.evaluate(&cert, "getCertificate", certID)
+	if err != nil {
+		return nil, err
+	}
+	return &cert, nil
+}
+
+// GetBadge returns a badge
+func (c *Client) GetBadge(badgeID string) (*Badge, error) {
+	var badge Badge
+	err := c.evaluate(&badge, "getCertificate", badgeID)
+	if err != nil {
+		return nil, err
+	}
+	return &badge, nil
+}
+
+// VerifyCertificate checks a certificate (integrity, revocation, expiry,
+// issuer and badge)
+func (c *Client) VerifyCertificate(certID string) (*Verdict, error) {
+	var verdict Verdict
+	err := c.evaluate(&verdict, "verifyCertificate", certID)
+	if err != nil {
+		return nil, err
+	}
+	return &verdict, nil
+}
+
+// GetCertificateDigest returns the canonical JSON (RFC 8785) of the issued
+// content of a certificate and its digest, the certificate hash
+func (c *Client) GetCertificateDigest(certID string) (*CertificateDigest, error) {
+	var digest CertificateDigest
+	err := c.evaluate(&digest, "getCertificateDigest", certID)
+	if err != nil {
+		return nil, err
+	}
+	return &digest, nil
+}
+
+// VerifyRecipientIdentity checks an email against the hashed recipient of a
+// certificate
+func (c *Client) VerifyRecipientIdentity(certID, email string) (*IdentityMatch, error) {
+	var match IdentityMatch
+	err := c.evaluate(&match, "verifyRecipientIdentity", certID, email)
+	if err != nil {
+		return nil, err
+	}
+	return &match, nil
+}
+
+// VerifyDocumentHash returns the certificates a document (SHA-256 digest,
+// hex) is attached to
+func (c *Client) VerifyDocumentHash(digest string) (*DocumentHashResult, error) {
+	var res DocumentHashResult
+	err := c.evaluate(&res, "verifyDocumentHash", digest)
+	if err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// QueryCertificates returns a page of the certificates matching selector
+func (c *Client) QueryCertificates(selector QuerySelector, page PageRequest) (*CertificatePage, error) {
+	selectorJSON, err := json.Marshal(selector)
+	if err != nil {
+		return nil, err
+	}
+	var certs CertificatePage
+	certs.FetchedCount, certs.Bookmark, err = c.evaluatePage(&certs.Certificates, "queryCertificates", string(selectorJSON), page)
+	if err != nil {
+		return nil, err
+	}
+	return &certs, nil
+}
+
+// ListCertificates returns a page of all the certificates, by key
+func (c *Client) ListCertificates(page PageRequest) (*CertificatePage, error) {
+	var certs CertificatePage
+	var err error
+	certs.FetchedCount, certs.Bookmark, err = c.evaluatePage(&certs.Certificates, "listCertificates", "", page)
+	if err != nil {
+		return nil, err
+	}
+	return &certs, nil
+}
+
+// ListIssuerCertificates returns a page of the certificates of an issuer,
+// by key
+func (c *Client) ListIssuerCertificates(issuer string, page PageRequest) (*CertificatePage, error) {
+	var certs CertificatePage
+	var err error
+	certs.FetchedCount, certs.Bookmark, err = c.evaluatePage(&certs.Certificates, "listIssuerCertificates", issuer, page)
+	if err != nil {
+		return nil, err
+	}
+	return &certs, nil
+}
+
+// QueryBadges returns a page of the badges matching selector (Issuer only)
+func (c *Client) QueryBadges(selector QuerySelector, page PageRequest) (*BadgePage, error) {
+	selectorJSON, err := json.Marshal(selector)
+	if err != nil {
+		return nil, err
+	}
+	var badges BadgePage
+	badges.FetchedCount, badges.Bookmark, err = c.evaluatePage(&badges.Badges, "queryBadges", string(selectorJSON), page)
+	if err != nil {
+		return nil, err
+	}
+	return &badges, nil
+}
+
+// ListBadges returns a page of all the badges, by key
+func (c *Client) ListBadges(page PageRequest) (*BadgePage, error) {
+	var badges BadgePage
+	var err error
+	badges.FetchedCount, badges.Bookmark, err = c.evaluatePage(&badges.Badges, "listBadges", "", page)
+	if err != nil {
+		return nil, err
+	}
+	return &badges, nil
+}
+
+// ListIssuerBadges returns a page of the badges of an issuer, by key
+func (c *Client) ListIssuerBadges(issuer string, page PageRequest) (*BadgePage, error) {
+	var badges BadgePage
+	var err error
+	badges.FetchedCount, badges.Bookmark, err = c.evaluatePage(&badges.Badges, "listIssuerBadges", issuer, page)
+	if err != nil {
+		return nil, err
+	}
+	return &badges, nil
+}
+
+// SearchBadges returns a page of the badge catalogue matching search
+func (c *Client) SearchBadges(search BadgeSearch, page PageRequest) (*BadgePage, error) {
+	searchJSON, err := json.Marshal(search)
+	if err != nil {
+		return nil, err
+	}
+	var badges BadgePage
+	badges.FetchedCount, badges.Bookmark, err = c.evaluatePage(&badges.Badges, "searchBadges", string(searchJSON), page)
+	if err != nil {
+		return nil, err
+	}
+	return &badges, nil
+}
+
+// GetIssuerStats returns the statistics of an issuer (email)
+func (c *Client) GetIssuerStats(issuerID string) (*Stats, error) {
+	var stats Stats
+	err := c.evaluate(&stats, "getIssuerStats", issuerID)
+	if err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
+
+// GetBadgeStats returns the statistics of a badge
+func (c *Client) GetBadgeStats(badgeID string) (*Stats, error) {
+	var stats Stats
+	err := c.evaluate(&stats, "getBadgeStats", badgeID)
+	if err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
+
+// ExportState returns a page of the world state (admins only). A page may
+// be short or empty while its bookmark is not: export until the bookmark is
+// empty.
+func (c *Client) ExportState(page PageRequest) (*StateBatch, error) {
+	var batch StateBatch
+	err := c.evaluate(&batch, "exportState", strconv.Itoa(page.PageSize), page.Bookmark)
+	if err != nil {
+		return nil, err
+	}
+	return &batch, nil
+}
+
+// ImportState replays a page of ExportState (admins only). The records must
+// be owned by issuers of the admin's org.
+func (c *Client) ImportState(batch StateBatch) (*Mutation, error) {
+	batch.Bookmark = ""
+	batchJSON, err := json.Marshal(batch)
+	if err != nil {
+		return nil, err
+	}
+
+	var res Mutation
+	err = c.submit(&res, "importState", []string{string(batchJSON)}, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// MigrateCertificates rewrites up to pageSize certificates issued before
+// badge references, from startKey (admins only). Call it with the NextKey
+// of the previous call until it is empty.
+func (c *Client) MigrateCertificates(pageSize int, startKey string) (*MigrationResult, error) {
+	var res MigrationResult
+	err := c.submit(&res, "migrateCertificates", []string{strconv.Itoa(pageSize), startKey}, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// RenewRecipientData rewrites the recipient private data of up to pageSize
+// certificates, from startKey, so that it doesn't expire (admins only).
+// Call it with the NextKey of the previous call until it is empty, over all
+// the certificates more often than every blockToLive blocks.
+func (c *Client) RenewRecipientData(pageSize int, startKey string) (*MigrationResult, error) {
+	var res MigrationResult
+	err := c.submit(&res, "renewRecipientData", []string{strconv.Itoa(pageSize), startKey}, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+func (c *Client) submit(v interface{}, function string, args []string, transient map[string][]byte) error {
+	payload, err := c.transport.Submit(function, args, transient)
+	if err != nil {
+		return chaincodeError(err)
+	}
+	return decode(function, payload, v)
+}
+
+func (c *Client) evaluate(v interface{}, function string, args ...string) error {
+	payload, err := c.transport.Evaluate(function, args, nil)
+	if err != nil {
+		return chaincodeError(err)
+	}
+	return decode(function, payload, v)
+}
+
+// evaluatePage calls a paginated function (filter is its first argument if
+// not empty) and decodes the records into records (pointer to slice)
+func (c *Client) evaluatePage(records interface{}, function, filter string, page PageRequest) (int, string, error) {
+	args := []string{strconv.Itoa(page.PageSize), page.Bookmark}
+	if filter != "" {
+		args = append([]string{filter}, args...)
+	}
+
+	var res struct {
+		Records []struct {
+			Record json.RawMessage `json:"record"`
+		} `json:"records"`
+		FetchedCount int    `json:"fetchedCount"`
+		Bookmark     string `json:"bookmark"`
+	}
+	err := c.evaluate(&res, function, args...)
+	if err != nil {
+		return 0, "", err
+	}
+
+	raw := make([]json.RawMessage, len(res.Records))
+	for i, record := range res.Records {
+		raw[i] = record.Record
+	}
+	rawJSON, err := json.Marshal(raw)
+	if err != nil {
+		return 0, "", err
+	}
+	err = decode(function, rawJSON, records)
+	if err != nil {
+		return 0, "", err
+	}
+
+	return res.FetchedCount, res.Bookmark, nil
+}
+
+// NewSecret returns a random 32 bytes secret (hex), to use as recipient
+// salt or org pepper
+func NewSecret() (string, error) {
+	secret := make([]byte, 32)
+	_, err := rand.Read(secret)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(secret), nil
+}
+
+func recipientTransient(recipient RecipientDetails) (map[string][]byte, error) {
+	recipientJSON, err := json.Marshal(recipient)
+	if err != nil {
+		return nil, err
+	}
+	return map[string][]byte{recipientTransientKey: recipientJSON}, nil
+}
+
+func decode(function string, payload []byte, v interface{}) error {
+	err := json.Unmarshal(payload, v)
+	if err != nil {
+		return errors.New("invalid " + function + " response: " + err.Error())
+	}
+	return nil
+}
+
+// chaincodeError returns the chaincode error carried by a transport error
+// (its message is the error payload) as an *ccerrors.Error
+func chaincodeError(err error) error {
+	if _, ok := err.(*ccerrors.Error); ok {
+		return err
+	}
+	if e, ok := ccerrors.Parse(err.Error()); ok {
+		return e
+	}
+	return err
+}
@@ -0,0 +1,90 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/certificates/go/ccerrors"
+)
+
+// fakeTransport records the last call and returns a fixed response
+type fakeTransport struct {
+	function  string
+	args      []string
+	transient map[string][]byte
+	payload   []byte
+	err       error
+}
+
+func (f *fakeTransport) Submit(function string, args []string, transient map[string][]byte) ([]byte, error) {
+	f.function, f.args, f.transient = function, args, transient
+	return f.payload, f.err
+}
+
+func (f *fakeTransport) Evaluate(function string, args []string, transient map[string][]byte) ([]byte, error) {
+	return f.Submit(function, args, transient)
+}
+
+func TestIssueCertificate(t *testing.T) {
+	transport := &fakeTransport{payload: []byte(`{"created":["cert:1"],"txId":"tx1"}`)}
+	res, err := New(transport).IssueCertificate(IssueCertificateRequest{
+		IssuedOn: time.Date(2018, 10, 17, 9, 29, 47, 0, time.FixedZone("CEST", 2*3600)),
+		Location: "Paris", Badge: "data-science", AttachmentDigests: []string{"a1", "b2"},
+		Recipient: RecipientDetails{Email: "student@example.com"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{"2018-10-17T07:29:47Z", "Paris", "data-science", "a1,b2", ""}
+	if transport.function != "issueCertificate" || !reflect.DeepEqual(transport.args, expected) {
+		t.Errorf("expected issueCertificate %v, got %s %v", expected, transport.function, transport.args)
+	}
+
+	var recipient RecipientDetails
+	if err := json.Unmarshal(transport.transient[recipientTransientKey], &recipient); err != nil {
+		t.Fatal(err)
+	}
+	if recipient.Email != "student@example.com" || len(recipient.Salt) != 64 {
+		t.Errorf("expected the recipient with a generated salt, got %+v", recipient)
+	}
+	if len(res.Created) != 1 || res.TxID != "tx1" {
+		t.Errorf("unexpected result %+v", res)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	for _, c := range []struct {
+		name string
+		err  error
+		code ccerrors.Code
+	}{
+		{"chaincode error payload", errors.New(ccerrors.New(ccerrors.NotFound, "Badge doesn't exist").JSON()), ccerrors.NotFound},
+		{"chaincode error", ccerrors.New(ccerrors.Forbidden, "Only admins can do that"), ccerrors.Forbidden},
+		{"transport error", errors.New("connection refused"), ""},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			_, err := New(&fakeTransport{err: c.err}).GetBadge("badge:issuer1@org1.example.com/data-science")
+
+			e, ok := err.(*ccerrors.Error)
+			switch {
+			case c.code == "" && ok:
+				t.Errorf("expected the transport error, got %v", err)
+			case c.code == "" && err != c.err:
+				t.Errorf("expected %v, got %v", c.err, err)
+			case c.code != "" && (!ok || e.Code != c.code):
+				t.Errorf("expected a %s error, got %v", c.code, err)
+			}
+		})
+	}
+}
+
+func TestInvalidResponse(t *testing.T) {
+	_, err := New(&fakeTransport{payload: []byte("not JSON")}).GetCertificate("cert:1")
+	if err == nil {
+		t.Error("expected an invalid response error")
+	}
+}
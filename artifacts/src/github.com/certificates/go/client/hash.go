@@ -0,0 +1,42 @@
+package client
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+
+	"github.com/certificates/go/canonicaljson"
+)
+
+// Offline checks, computed like the chaincode (see computeCertificateHash
+// and hashRecipientIdentity)
+
+// CertificateHash computes the integrity hash (sha256$<hex>) of a
+// certificate over the canonical JSON (RFC 8785) of its issued content.
+// Status fields (revocation, erasure), the recipient profile and the hash
+// itself are excluded.
+func CertificateHash(cert Certificate) (string, error) {
+	out, err := canonicaljson.Marshal(hashContent(cert))
+	if err != nil {
+		return "", err
+	}
+	return hashBytes(out), nil
+}
+
+func hashContent(cert Certificate) Certificate {
+	cert.Hash = ""
+	cert.Revoked, cert.RevocationReason = false, ""
+	cert.SubjectErased = false
+	cert.RecipientProfile = nil
+	return cert
+}
+
+func hashBytes(data []byte) string {
+	sum := sha256.Sum256(data)
+	return "sha256$" + hex.EncodeToString(sum[:])
+}
+
+// HashRecipient computes the Open Badges hashed identity of a recipient:
+// sha256$<hex(sha256(identity + salt))>
+func HashRecipient(identity, salt string) string {
+	return hashBytes([]byte(identity + salt))
+}
@@ -0,0 +1,323 @@
+package client
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// Requests
+
+// IssueBadgeRequest is the input of issueBadge. The issuer email is the
+// email attribute of the caller certificate.
+type IssueBadgeRequest struct {
+	IssuerName        string
+	IssuerURL         string
+	Name              string
+	Description       string
+	Criteria          string
+	SignatureJobTitle string
+	SignatureName     string
+	// Optional catalogue fields
+	Tags     []string
+	Category string
+	Level    string
+	Language string
+	// Slug of the badge ID (badge:<issuerEmail>/<slug>), derived from Name
+	// if empty
+	Slug string
+}
+
+func (r IssueBadgeRequest) args() []string {
+	return []string{
+		r.IssuerName, r.IssuerURL, r.Name, r.Description, r.Criteria,
+		r.SignatureJobTitle, r.SignatureName,
+		strings.Join(r.Tags, ","), r.Category, r.Level, r.Language, r.Slug,
+	}
+}
+
+// IssueCertificateRequest is the input of issueCertificate. The recipient is
+// sent in the transient map and only its salted hash reaches the ledger.
+type IssueCertificateRequest struct {
+	IssuedOn time.Time
+	Location string
+	// Badge is a slug of the caller's badges or a full badge ID
+	Badge     string
+	Recipient RecipientDetails
+	// SHA-256 digests (hex) of the attached documents
+	AttachmentDigests []string
+	// Expires is optional, the zero time never expires
+	Expires time.Time
+}
+
+func (r IssueCertificateRequest) args() []string {
+	return []string{
+		r.IssuedOn.UTC().Format(time.RFC3339), r.Location, r.Badge,
+		strings.Join(r.AttachmentDigests, ","), formatOptionalTime(r.Expires),
+	}
+}
+
+func formatOptionalTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// RecipientDetails is the recipient private data. The salt must be random
+// and is only needed to issue certificates, IssueCertificate generates one
+// when it is empty.
+type RecipientDetails struct {
+	Email     string `json:"email"`
+	Name      string `json:"name"`
+	PublicKey string `json:"publicKey"`
+	Salt      string `json:"salt"`
+}
+
+// PageRequest selects a page of a list or query. An empty bookmark returns
+// the first page.
+type PageRequest struct {
+	PageSize int
+	Bookmark string
+}
+
+// QuerySelector filters queryCertificates and queryBadges (rich queries,
+// CouchDB only)
+type QuerySelector struct {
+	Issuer       string `json:"issuer,omitempty"`
+	Badge        string `json:"badge,omitempty"`     // badge ID, or slug of Issuer
+	Recipient    string `json:"recipient,omitempty"` // hashed identity (sha256$...)
+	IssuedOnFrom string `json:"issuedOnFrom,omitempty"`
+	IssuedOnTo   string `json:"issuedOnTo,omitempty"`
+	Status       string `json:"status,omitempty"` // active, revoked or erased
+}
+
+// BadgeSearch are the searchBadges criteria, all provided criteria must
+// match
+type BadgeSearch struct {
+	Text     string   `json:"text,omitempty"`
+	Tags     []string `json:"tags,omitempty"`
+	Category string   `json:"category,omitempty"`
+	Level    string   `json:"level,omitempty"`
+	Language string   `json:"language,omitempty"`
+}
+
+// Responses
+
+// Mutation is the result of every function that changes the ledger
+type Mutation struct {
+	Created   []string `json:"created"`
+	Updated   []string `json:"updated"`
+	TxID      string   `json:"txId"`
+	Timestamp string   `json:"timestamp"`
+}
+
+// BadgeResult is the result of IssueBadge
+type BadgeResult struct {
+	Mutation
+	Badge Badge `json:"object"`
+}
+
+// CertificateResult is the result of IssueCertificate
+type CertificateResult struct {
+	Mutation
+	Certificate Certificate `json:"object"`
+}
+
+// MigrationResult is the result of MigrateCertificates and
+// RenewRecipientData, Updated lists the migrated or renewed certificates
+type MigrationResult struct {
+	Mutation
+	Progress MigrationPage `json:"object"`
+}
+
+type MigrationPage struct {
+	Scanned int    `json:"scanned"`
+	NextKey string `json:"nextKey"`
+}
+
+// CertificatePage is a page of certificates
+type CertificatePage struct {
+	Certificates []Certificate
+	FetchedCount int
+	Bookmark     string
+}
+
+// BadgePage is a page of badges
+type BadgePage struct {
+	Badges       []Badge
+	FetchedCount int
+	Bookmark     string
+}
+
+// IdentityMatch is the result of VerifyRecipientIdentity
+type IdentityMatch struct {
+	Id            string `json:"id"`
+	Match         bool   `json:"match"`
+	SubjectErased bool   `json:"subjectErased"`
+}
+
+// Open Badges documents, as stored in the ledger
+
+type Certificate struct {
+	Context          string            `json:"@context"`
+	Id               string            `json:"id"`
+	Type             string            `json:"type"`
+	IssuedOn         string            `json:"issuedOn"`
+	Recipient        Recipient         `json:"recipient"`
+	RecipientProfile *RecipientProfile `json:"recipientProfile,omitempty"`
+	Verification     Verification      `json:"verification"`
+	Badge            Badge             `json:"badge"`
+	Attachments      []Attachment      `json:"attachments,omitempty"`
+	Expires          string            `json:"expires,omitempty"`
+	Revoked          bool              `json:"revoked,omitempty"`
+	RevocationReason string            `json:"revocationReason,omitempty"`
+	SubjectErased    bool              `json:"subjectErased,omitempty"`
+	Hash             string            `json:"hash,omitempty"`
+}
+
+type Recipient struct {
+	Identity string `json:"identity"`
+	Type     string `json:"type"`
+	Hashed   bool   `json:"hashed"`
+	Salt     string `json:"salt,omitempty"`
+}
+
+type RecipientProfile struct {
+	PublicKey string   `json:"publicKey"`
+	Name      string   `json:"name"`
+	Type      []string `json:"type"`
+}
+
+type Verification struct {
+	Location string   `json:"location"`
+	Type     []string `json:"type"`
+}
+
+type Attachment struct {
+	Digest string   `json:"digest"`
+	Type   []string `json:"type"`
+}
+
+type Badge struct {
+	Id             string           `json:"id"`
+	Name           string           `json:"name"`
+	Type           string           `json:"type"`
+	Issuer         Issuer           `json:"issuer"`
+	Criteria       Criteria         `json:"criteria"`
+	Image          string           `json:"image"`
+	Description    string           `json:"description"`
+	SignatureLines []SignatureLines `json:"signatureLines"`
+	Tags           []string         `json:"tags,omitempty"`
+	Category       string           `json:"category,omitempty"`
+	Level          string           `json:"level,omitempty"`
+	Language       string           `json:"language,omitempty"`
+}
+
+type Issuer struct {
+	Id             string `json:"id"`
+	Url            string `json:"url"`
+	Name           string `json:"name"`
+	Email          string `json:"email"`
+	Type           string `json:"type"`
+	RevocationList string `json:"revocationList"`
+}
+
+type Criteria struct {
+	Narrative string `json:"narrative"`
+}
+
+type SignatureLines struct {
+	JobTitle string   `json:"jobTitle"`
+	Name     string   `json:"name"`
+	Type     []string `json:"type"`
+	Image    string   `json:"image"`
+}
+
+// Verification results
+
+// Verdict is the result of VerifyCertificate
+type Verdict struct {
+	Id               string        `json:"id"`
+	Valid            bool          `json:"valid"`
+	Exists           bool          `json:"exists"`
+	Integrity        Integrity     `json:"integrity"`
+	Revoked          bool          `json:"revoked"`
+	RevocationReason string        `json:"revocationReason,omitempty"`
+	Expired          bool          `json:"expired"`
+	Expires          string        `json:"expires,omitempty"`
+	IssuerAccredited bool          `json:"issuerAccredited"`
+	Badge            BadgeCheck    `json:"badge"`
+	SubjectErased    bool          `json:"subjectErased"`
+	Failures         []FailedCheck `json:"failures"`
+}
+
+type Integrity struct {
+	Valid    bool   `json:"valid"`
+	Stored   string `json:"stored"`
+	Computed string `json:"computed"`
+}
+
+type BadgeCheck struct {
+	Id      string `json:"id"`
+	Version string `json:"version"`
+	Current bool   `json:"current"`
+}
+
+// FailedCheck is a failed verification check (code REVOKED, EXPIRED...)
+type FailedCheck struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// DocumentHashResult is the result of VerifyDocumentHash
+type DocumentHashResult struct {
+	Hash         string              `json:"hash"`
+	Certificates []CertificateStatus `json:"certificates"`
+}
+
+type CertificateStatus struct {
+	Id       string `json:"id"`
+	Status   string `json:"status"` // active, revoked, expired or erased
+	Issuer   string `json:"issuer"`
+	Badge    string `json:"badge"`
+	IssuedOn string `json:"issuedOn"`
+}
+
+// CertificateDigest is the result of GetCertificateDigest
+type CertificateDigest struct {
+	Id               string `json:"id"`
+	Digest           string `json:"digest"`           // sha256$<hex> of Document
+	Canonicalization string `json:"canonicalization"` // RFC8785
+	// Document is the canonical issued content of the certificate
+	Document json.RawMessage `json:"document"`
+}
+
+// StateBatch is a page of ExportState and a batch of ImportState
+type StateBatch struct {
+	Records  []StateRecord `json:"records"`
+	Checksum string        `json:"checksum"`
+	Bookmark string        `json:"bookmark,omitempty"`
+}
+
+type StateRecord struct {
+	Key    string `json:"key"`
+	Value  []byte `json:"value"`
+	Policy []byte `json:"policy,omitempty"`
+}
+
+// Stats are the issuance statistics of an issuer or a badge
+type Stats struct {
+	Issuer  string                 `json:"issuer"`
+	Badge   string                 `json:"badge,omitempty"`
+	Total   int                    `json:"total"`
+	Revoked int                    `json:"revoked"`
+	Erased  int                    `json:"erased"`
+	Months  map[string]MonthlyStat `json:"months"`
+}
+
+type MonthlyStat struct {
+	Issued  int `json:"issued"`
+	Revoked int `json:"revoked"`
+	Erased  int `json:"erased"`
+}
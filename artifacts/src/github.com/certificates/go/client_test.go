@@ -0,0 +1,248 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/certificates/go/ccerrors"
+	"github.com/certificates/go/cctest"
+	"github.com/certificates/go/client"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	pb "github.com/hyperledger/fabric/protos/peer"
+)
+
+// stubTransport is a client.Transport running the chaincode on a mock stub
+type stubTransport struct {
+	stub *cctest.Stub
+}
+
+func newStubTransport(identity *cctest.Identity) *stubTransport {
+	return &stubTransport{stub: cctest.NewStub("certificates", new(SimpleChaincode)).As(identity)}
+}
+
+func (t *stubTransport) Submit(function string, args []string, transient map[string][]byte) ([]byte, error) {
+	return transportResponse(t.stub.InvokeWithTransient(transient, function, args...))
+}
+
+func (t *stubTransport) Evaluate(function string, args []string, transient map[string][]byte) ([]byte, error) {
+	return transportResponse(t.stub.QueryWithTransient(transient, function, args...))
+}
+
+func transportResponse(resp pb.Response) ([]byte, error) {
+	if resp.Status >= shim.ERRORTHRESHOLD {
+		if e, ok := ccerrors.Parse(resp.Message); ok {
+			return nil, e
+		}
+		return nil, errors.New(resp.Message)
+	}
+	return resp.Payload, nil
+}
+
+// TestClient runs an issuance flow through the typed client (package
+// client), on its own ledger
+func TestClient(t *testing.T) {
+	f := newLedger(t)
+	c := client.New(newStubTransport(f.admin))
+	badgeID := makeBadgeID(testIssuer1, "go-101")
+
+	_, err := c.InitLedger()
+	fatalOnError(t, err)
+	pepper, err := client.NewSecret()
+	fatalOnError(t, err)
+	_, err = c.SetRecipientPepper(pepper)
+	fatalOnError(t, err)
+
+	badgeReq := client.IssueBadgeRequest{
+		IssuerName: "University", IssuerURL: "https://org1.example.com", Name: "Go 101",
+		Description: "Badge description", Criteria: "Badge criteria",
+		SignatureJobTitle: "Dean", SignatureName: "Signature", Tags: []string{"go", "programming"},
+	}
+	badgeRes, err := c.IssueBadge(badgeReq)
+	fatalOnError(t, err)
+	if badgeRes.Badge.Id != badgeID || len(badgeRes.Badge.Tags) != 2 {
+		t.Errorf("expected badge %s with 2 tags, got %+v", badgeID, badgeRes.Badge)
+	}
+
+	_, err = c.IssueBadge(badgeReq)
+	if ccerrors.CodeOf(err) != ccerrors.AlreadyExists {
+		t.Errorf("expected a typed %s error, got %v", ccerrors.AlreadyExists, err)
+	}
+
+	certRes, err := c.IssueCertificate(client.IssueCertificateRequest{
+		IssuedOn: cctest.DefaultClock, Location: "https://org1.example.com/verify", Badge: "go-101",
+		Recipient:         client.RecipientDetails{Email: testRecipient, Name: "Student"},
+		AttachmentDigests: []string{testDigest},
+	})
+	fatalOnError(t, err)
+	certID := certRes.Certificate.Id
+	if certRes.Certificate.Badge.Id != badgeID || certRes.Certificate.IssuedOn != "2018-10-17T07:29:47Z" {
+		t.Errorf("expected a certificate of %s, got %+v", badgeID, certRes.Certificate)
+	}
+
+	t.Run("CertificateHash", func(t *testing.T) {
+		// offline hash (ccverify) must match the chaincode hash
+		hash, err := client.CertificateHash(certRes.Certificate)
+		fatalOnError(t, err)
+		if hash != certRes.Certificate.Hash {
+			t.Errorf("expected %s, got %s", certRes.Certificate.Hash, hash)
+		}
+	})
+
+	t.Run("GetCertificateDigest", func(t *testing.T) {
+		digest, err := c.GetCertificateDigest(certID)
+		fatalOnError(t, err)
+		if digest.Digest != certRes.Certificate.Hash {
+			t.Errorf("expected %s, got %s", certRes.Certificate.Hash, digest.Digest)
+		}
+	})
+
+	t.Run("MigrateCertificates", func(t *testing.T) {
+		migration, err := c.MigrateCertificates(10, "")
+		fatalOnError(t, err)
+		if len(migration.Updated) != 0 || migration.Progress.Scanned != 1 || migration.Progress.NextKey != "" {
+			t.Errorf("expected 1 certificate scanned, none to migrate, got %+v", migration)
+		}
+	})
+
+	t.Run("GetCertificate", func(t *testing.T) {
+		cert, err := c.GetCertificate(certID)
+		fatalOnError(t, err)
+		if cert.RecipientProfile == nil || cert.RecipientProfile.Name != "Student" {
+			t.Errorf("expected the recipient profile, got %+v", cert.RecipientProfile)
+		}
+	})
+
+	t.Run("VerifyCertificate", func(t *testing.T) {
+		verdict, err := c.VerifyCertificate(certID)
+		fatalOnError(t, err)
+		if !verdict.Valid {
+			t.Errorf("expected a valid certificate, got %+v", verdict)
+		}
+	})
+
+	t.Run("ListBadges", func(t *testing.T) {
+		badges, err := c.ListBadges(client.PageRequest{PageSize: 10})
+		fatalOnError(t, err)
+		if len(badges.Badges) != 1 || badges.Badges[0].Id != badgeID {
+			t.Errorf("expected 1 badge, got %+v", badges)
+		}
+	})
+
+	// migration: export, import into an empty ledger, export again
+	t.Run("ImportState", func(t *testing.T) {
+		exported, err := exportAll(c)
+		fatalOnError(t, err)
+
+		migrated := client.New(newStubTransport(f.admin))
+		for _, batch := range exported {
+			if len(batch.Records) > 0 {
+				_, err = migrated.ImportState(batch)
+				fatalOnError(t, err)
+			}
+		}
+
+		reexported, err := exportAll(migrated)
+		fatalOnError(t, err)
+		if !reflect.DeepEqual(reexported, exported) {
+			t.Errorf("expected the same state after migration, got %+v", reexported)
+		}
+
+		verdict, err := migrated.VerifyCertificate(certID)
+		fatalOnError(t, err)
+		if !verdict.Valid {
+			t.Errorf("expected a valid migrated certificate, got %+v", verdict)
+		}
+	})
+}
+
+// exportAll exports the whole world state, in small pages
+func exportAll(c *client.Client) ([]client.StateBatch, error) {
+	var batches []client.StateBatch
+	page := client.PageRequest{PageSize: 3}
+	for {
+		batch, err := c.ExportState(page)
+		if err != nil {
+			return nil, err
+		}
+		batch.Bookmark, page.Bookmark = "", batch.Bookmark
+		batches = append(batches, *batch)
+		if page.Bookmark == "" {
+			return batches, nil
+		}
+	}
+}
+
+// The client types are copies of the chaincode types, they must have the
+// same JSON fields
+func TestClientTypes(t *testing.T) {
+	tests := []struct {
+		client, chaincode interface{}
+	}{
+		{client.RecipientDetails{}, RecipientPrivateDetails{}},
+		{client.QuerySelector{}, QuerySelector{}},
+		{client.BadgeSearch{}, BadgeSearch{}},
+		{client.MigrationPage{}, MigrationPage{}},
+		{client.Certificate{}, Certificate{}},
+		{client.Badge{}, Badge{}},
+		{client.Verdict{}, VerificationVerdict{}},
+		{client.DocumentHashResult{}, DocumentHashResult{}},
+		{client.CertificateDigest{}, CertificateDigest{}},
+		{client.StateBatch{}, StateBatch{}},
+		{client.Stats{}, Stats{}},
+	}
+
+	for _, test := range tests {
+		clientType, chaincodeType := reflect.TypeOf(test.client), reflect.TypeOf(test.chaincode)
+		t.Run(clientType.Name(), func(t *testing.T) {
+			clientShape, chaincodeShape := jsonShape(clientType), jsonShape(chaincodeType)
+			if !reflect.DeepEqual(clientShape, chaincodeShape) {
+				t.Errorf("client.%s doesn't match %s:\n  client:    %v\n  chaincode: %v",
+					clientType.Name(), chaincodeType.Name(), clientShape, chaincodeShape)
+			}
+		})
+	}
+}
+
+var rawMessageType = reflect.TypeOf(json.RawMessage{})
+
+// jsonShape returns the JSON fields of a type and their JSON types
+func jsonShape(t reflect.Type) interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == rawMessageType {
+		return "any"
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		fields := make(map[string]interface{})
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			name, _ := jsonFieldName(field)
+			if field.PkgPath != "" || name == "-" {
+				continue
+			}
+			fields[name] = jsonShape(field.Type)
+		}
+		return fields
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			// base64
+			return "string"
+		}
+		return []interface{}{jsonShape(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"*": jsonShape(t.Elem())}
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "bool"
+	case reflect.Interface:
+		return "any"
+	default:
+		return "number"
+	}
+}
@@ -0,0 +1,167 @@
+// Command ccidentity mints local development identities, offline, from the
+// organization CAs generated by cryptogen.
+//
+// The user certificate carries Fabric CA attributes (in the extension read
+// by attrmgr and cid), so role and email gated chaincode functions can be
+// called without enrolling users in a Fabric CA server:
+//
+// From the repository root (GOPATH=$PWD/artifacts):
+//
+//	go run github.com/certificates/go/cmd/ccidentity -org org1.example.com \
+//		-name issuer1 -attr role=university -attr email=issuer1@org1.example.com
+//
+// It writes an MSP folder (signcerts, keystore, cacerts, admincerts,
+// tlscacerts) under -out/<name>@<org>/msp, laid out like the cryptogen users
+// so the SDKs can load it. admincerts is left empty unless -admin is set: a
+// certificate listed there is an MSP admin of the org. The certificate
+// doesn't outlive the org CA, whatever -days.
+package main
+
+import (
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/certificates/go/internal/kvflag"
+	"github.com/certificates/go/mspidentity"
+)
+
+func main() {
+	attrs := kvflag.KeyValues{}
+	cryptoConfig := flag.String("crypto-config", "artifacts/channel/crypto-config", "cryptogen output folder")
+	org := flag.String("org", "", "organization domain, e.g. org1.example.com")
+	mspID := flag.String("msp-id", "", "MSP ID (default derived from the org, e.g. Org1MSP)")
+	name := flag.String("name", "", "user name, the certificate CN is <name>@<org>")
+	out := flag.String("out", "identities", "output folder")
+	days := flag.Int("days", 365, "certificate validity in days")
+	admin := flag.Bool("admin", false, "list the certificate in admincerts (MSP admin of the org)")
+	flag.Var(attrs, "attr", "certificate attribute name=value (repeatable), e.g. role=university")
+	flag.Parse()
+
+	if *org == "" || *name == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if *mspID == "" {
+		*mspID = deriveMSPID(*org)
+	}
+	orgDir := filepath.Join(*cryptoConfig, "peerOrganizations", *org)
+	ca, err := loadOrgCA(orgDir, *mspID)
+	exitOnError(err)
+
+	commonName := *name + "@" + *org
+	identity, err := ca.NewIdentity(commonName, attrs, time.Duration(*days)*24*time.Hour)
+	exitOnError(err)
+
+	mspDir := filepath.Join(*out, commonName, "msp")
+	keyFile, err := writeMSP(mspDir, orgDir, ca, identity, *admin)
+	exitOnError(err)
+
+	fmt.Printf("MSP ID:      %s\n", *mspID)
+	fmt.Printf("Attributes:  %s\n", attrs)
+	fmt.Printf("Expires:     %s\n", identity.Cert.NotAfter.Format(time.RFC3339))
+	fmt.Printf("Certificate: %s\n", filepath.Join(mspDir, "signcerts", commonName+"-cert.pem"))
+	fmt.Printf("Private key: %s\n", keyFile)
+}
+
+// deriveMSPID follows the crypto-config convention: org1.example.com ->
+// Org1MSP
+func deriveMSPID(org string) string {
+	label := strings.SplitN(org, ".", 2)[0]
+	if label == "" {
+		return "MSP"
+	}
+	return strings.ToUpper(label[:1]) + label[1:] + "MSP"
+}
+
+// loadOrgCA loads <org>/ca/ca.<org>-cert.pem and its *_sk private key
+func loadOrgCA(orgDir, mspID string) (*mspidentity.CA, error) {
+	caDir := filepath.Join(orgDir, "ca")
+
+	certPEM, err := ioutil.ReadFile(caCertPath(orgDir))
+	if err != nil {
+		return nil, err
+	}
+
+	keyFiles, err := filepath.Glob(filepath.Join(caDir, "*_sk"))
+	if err != nil {
+		return nil, err
+	}
+	if len(keyFiles) != 1 {
+		return nil, fmt.Errorf("expecting 1 CA private key (*_sk) in %s, found %d", caDir, len(keyFiles))
+	}
+	keyPEM, err := ioutil.ReadFile(keyFiles[0])
+	if err != nil {
+		return nil, err
+	}
+
+	return mspidentity.LoadCA(mspID, certPEM, keyPEM)
+}
+
+type mspFile struct {
+	path    string
+	content []byte
+	mode    os.FileMode
+}
+
+// writeMSP writes the MSP folder of identity and returns the private key
+// path. The identity is listed in admincerts only if admin is set.
+func writeMSP(mspDir, orgDir string, ca *mspidentity.CA, identity *mspidentity.Identity, admin bool) (string, error) {
+	commonName := identity.Cert.Subject.CommonName
+	keyPEM, err := identity.KeyPEM()
+	if err != nil {
+		return "", err
+	}
+	keyFile := filepath.Join(mspDir, "keystore", hex.EncodeToString(mspidentity.SubjectKeyID(&identity.Key.PublicKey))+"_sk")
+
+	files := []mspFile{
+		{filepath.Join(mspDir, "signcerts", commonName+"-cert.pem"), identity.CertPEM, 0644},
+		{filepath.Join(mspDir, "cacerts", filepath.Base(caCertPath(orgDir))), ca.CertPEM, 0644},
+		{keyFile, keyPEM, 0600},
+	}
+
+	if admin {
+		files = append(files, mspFile{filepath.Join(mspDir, "admincerts", commonName+"-cert.pem"), identity.CertPEM, 0644})
+	}
+
+	// TLS CA is optional (orgs without TLS)
+	tlsCAPath := filepath.Join(orgDir, "tlsca", "tlsca."+filepath.Base(orgDir)+"-cert.pem")
+	if tlsCAPEM, err := ioutil.ReadFile(tlsCAPath); err == nil {
+		files = append(files, mspFile{filepath.Join(mspDir, "tlscacerts", filepath.Base(tlsCAPath)), tlsCAPEM, 0644})
+	}
+
+	// The MSP folder layout expects admincerts, even empty
+	err = os.MkdirAll(filepath.Join(mspDir, "admincerts"), 0755)
+	if err != nil {
+		return "", err
+	}
+
+	for _, file := range files {
+		err = os.MkdirAll(filepath.Dir(file.path), 0755)
+		if err != nil {
+			return "", err
+		}
+		err = ioutil.WriteFile(file.path, file.content, file.mode)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return keyFile, nil
+}
+
+func caCertPath(orgDir string) string {
+	return filepath.Join(orgDir, "ca", "ca."+filepath.Base(orgDir)+"-cert.pem")
+}
+
+func exitOnError(err error) {
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "ccidentity:", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,141 @@
+// Command ccsign signs a batch of certificates offline, with the issuer key:
+// it builds the Merkle tree of their hashes and signs its root, writing the
+// proof of each certificate for cmd/ccverify (MerkleProof2017 with the
+// issuer signature, see package merkleproof):
+//
+//	go run github.com/certificates/go/cmd/ccsign \
+//		-key identities/issuer1@org1.example.com/msp/keystore/<ski>_sk \
+//		-out proofs digest1.json digest2.json
+//
+// The inputs are getCertificateDigest results (JSON). Each digest is
+// checked against its canonical document before signing. The proof of
+// digest1.json is written to -out/digest1.proof.json, to be passed to
+// ccverify with -proof or set as the "signature" member of the exported
+// certificate. The issuer publishes the matching certificate, or public key
+// with an "Issuer: <issuer ID>" PEM header (ccverify -keys).
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/certificates/go/canonicaljson"
+	"github.com/certificates/go/client"
+	"github.com/certificates/go/merkleproof"
+)
+
+func main() {
+	keyFile := flag.String("key", "", "issuer private key (PEM, PKCS#8 or EC)")
+	out := flag.String("out", "proofs", "output folder")
+	flag.Parse()
+
+	if *keyFile == "" || flag.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "usage: ccsign -key <issuer key> [-out <folder>] <digest.json>...")
+		flag.PrintDefaults()
+		os.Exit(2)
+	}
+
+	key, err := readKey(*keyFile)
+	exitOnError(err)
+
+	hashes := make([]string, flag.NArg())
+	for i, path := range flag.Args() {
+		hashes[i], err = readDigest(path)
+		exitOnError(err)
+	}
+
+	proofs, err := merkleproof.Build(hashes)
+	exitOnError(err)
+	exitOnError(merkleproof.Sign(proofs, key))
+
+	exitOnError(os.MkdirAll(*out, 0755))
+	for i, path := range flag.Args() {
+		data, err := json.MarshalIndent(proofs[i], "", "  ")
+		exitOnError(err)
+
+		name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)) + ".proof.json"
+		exitOnError(ioutil.WriteFile(filepath.Join(*out, name), data, 0644))
+	}
+
+	fmt.Printf("Signed %d certificates, Merkle root %s\n", len(proofs), proofs[0].MerkleRoot)
+}
+
+// readDigest reads a getCertificateDigest result and returns its digest,
+// once checked against the document
+func readDigest(path string) (string, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	var digest client.CertificateDigest
+	err = json.Unmarshal(data, &digest)
+	if err != nil {
+		return "", errors.New(path + ": " + err.Error())
+	}
+
+	canonical, err := canonicaljson.Transform(digest.Document)
+	if err != nil {
+		return "", errors.New(path + ": invalid document: " + err.Error())
+	}
+	if string(canonical) != string(digest.Document) {
+		return "", errors.New(path + ": document is not canonical JSON")
+	}
+	sum := sha256.Sum256(digest.Document)
+	if digest.Digest != "sha256$"+hex.EncodeToString(sum[:]) {
+		return "", errors.New(path + ": digest " + digest.Digest + " doesn't match the document of " + digest.Id)
+	}
+	return digest.Digest, nil
+}
+
+// readKey reads an ECDSA private key (PKCS#8, as in the MSP keystore, or
+// SEC 1)
+func readKey(path string) (*ecdsa.PrivateKey, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New(path + ": no PEM private key")
+	}
+
+	switch block.Type {
+	case "PRIVATE KEY":
+		key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, errors.New(path + ": " + err.Error())
+		}
+		ecKey, ok := key.(*ecdsa.PrivateKey)
+		if !ok {
+			return nil, errors.New(path + ": only ECDSA keys are supported")
+		}
+		return ecKey, nil
+	case "EC PRIVATE KEY":
+		key, err := x509.ParseECPrivateKey(block.Bytes)
+		if err != nil {
+			return nil, errors.New(path + ": " + err.Error())
+		}
+		return key, nil
+	default:
+		return nil, errors.New(path + ": unsupported PEM block " + block.Type)
+	}
+}
+
+func exitOnError(err error) {
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "ccsign:", err)
+		os.Exit(2)
+	}
+}
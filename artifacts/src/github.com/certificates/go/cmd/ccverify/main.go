@@ -0,0 +1,373 @@
+// Command ccverify verifies an exported certificate offline, without access
+// to the peers:
+//
+//	go run github.com/certificates/go/cmd/ccverify -cert cert.json \
+//		-keys issuers.pem -proof proof.json \
+//		-email student@example.com -revocation-list revoked.json
+//
+// Checks:
+//
+//   - integrity: the certificate hash matches its content (canonical JSON,
+//     RFC 8785). Exports of the issuing organization carry the recipient in
+//     clear text, the hash is then computed with the recipient salt (-salt).
+//   - merkle: the certificate hash is a leaf of the Merkle proof
+//     (MerkleProof2017 made by cmd/ccsign, from -proof or the "signature"
+//     member of the certificate file)
+//   - signature: the Merkle root is signed by a key of the certificate
+//     issuer (badge.issuer.id) in the key bundle (-keys). The bundle holds
+//     PEM certificates, of the issuer email (Fabric CA "email" attribute,
+//     email SAN or common name), or PEM public keys with an "Issuer: <id>"
+//     header. Keys of other issuers are ignored.
+//   - recipient: -email matches the hashed recipient identity
+//   - revocation: the certificate is neither flagged revoked nor listed in
+//     the revocation list snapshot (Open Badges RevocationList JSON)
+//   - expiry: the certificate is not expired
+//
+// Checks whose input is not supplied are skipped. The integrity check only
+// shows that the certificate is self-consistent: anyone can compute a hash.
+// The certificate is authentic only if the merkle and signature checks
+// pass, so the verdict is:
+//
+//   - VALID (exit status 0): every check passed, merkle and signature
+//     included
+//   - INVALID (exit status 1): a check failed
+//   - INCONCLUSIVE (exit status 3): no check failed, but the merkle or
+//     signature check was skipped (no proof or no issuer keys)
+//
+// The exit status is 2 on usage or input errors. The report is printed as
+// text, or JSON with -json.
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/certificates/go/client"
+	"github.com/certificates/go/merkleproof"
+	"github.com/hyperledger/fabric/common/attrmgr"
+)
+
+const (
+	PASSED  = "passed"
+	FAILED  = "failed"
+	SKIPPED = "skipped"
+)
+
+// verdicts
+const (
+	VALID        = "VALID"
+	INVALID      = "INVALID"
+	INCONCLUSIVE = "INCONCLUSIVE"
+)
+
+// exit status of each verdict (2 is usage and input errors)
+var exitStatus = map[string]int{VALID: 0, INVALID: 1, INCONCLUSIVE: 3}
+
+// RevocationList is an Open Badges revocation list snapshot
+type RevocationList struct {
+	RevokedAssertions []RevokedAssertion `json:"revokedAssertions"`
+}
+
+type RevokedAssertion struct {
+	Id               string `json:"id"`
+	RevocationReason string `json:"revocationReason,omitempty"`
+}
+
+// certificateFile is a certificate export, optionally with its proof
+type certificateFile struct {
+	client.Certificate
+	Signature *merkleproof.Proof `json:"signature,omitempty"`
+}
+
+type Report struct {
+	Id      string `json:"id"`
+	Verdict string `json:"verdict"` // VALID, INVALID or INCONCLUSIVE
+	// Valid is true for a VALID verdict only
+	Valid  bool    `json:"valid"`
+	Checks []Check `json:"checks"`
+}
+
+type Check struct {
+	Name    string `json:"name"`
+	Status  string `json:"status"` // passed, failed or skipped
+	Message string `json:"message,omitempty"`
+}
+
+func main() {
+	certFile := flag.String("cert", "", "exported certificate (JSON)")
+	proofFile := flag.String("proof", "", "Merkle proof (JSON), default the signature member of the certificate")
+	keysFile := flag.String("keys", "", "issuer key bundle (PEM certificates or public keys)")
+	email := flag.String("email", "", "expected recipient email")
+	salt := flag.String("salt", "", "recipient salt, for certificates with a clear text recipient")
+	revocationFile := flag.String("revocation-list", "", "revocation list snapshot (JSON)")
+	asJSON := flag.Bool("json", false, "print the report as JSON")
+	flag.Parse()
+
+	if *certFile == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	var cert certificateFile
+	exitOnError(readJSON(*certFile, &cert))
+
+	proof := cert.Signature
+	if *proofFile != "" {
+		proof = &merkleproof.Proof{}
+		exitOnError(readJSON(*proofFile, proof))
+	}
+
+	var keys merkleproof.KeyBundle
+	if *keysFile != "" {
+		var err error
+		keys, err = readKeys(*keysFile)
+		exitOnError(err)
+	}
+
+	var revocationList *RevocationList
+	if *revocationFile != "" {
+		revocationList = &RevocationList{}
+		exitOnError(readJSON(*revocationFile, revocationList))
+	}
+
+	report := verify(cert.Certificate, *salt, proof, keys, *email, revocationList, time.Now())
+	if *asJSON {
+		out, err := json.MarshalIndent(report, "", "  ")
+		exitOnError(err)
+		fmt.Println(string(out))
+	} else {
+		printReport(report)
+	}
+
+	os.Exit(exitStatus[report.Verdict])
+}
+
+func verify(cert client.Certificate, salt string, proof *merkleproof.Proof, keys merkleproof.KeyBundle, email string, revocationList *RevocationList, now time.Time) Report {
+	report := Report{Id: cert.Id}
+	add := func(name, status, message string) {
+		report.Checks = append(report.Checks, Check{Name: name, Status: status, Message: message})
+	}
+
+	// 1. Integrity
+	// ------------
+	issued := cert
+	if !cert.Recipient.Hashed && salt != "" {
+		issued.Recipient = client.Recipient{
+			Identity: client.HashRecipient(cert.Recipient.Identity, salt),
+			Type:     cert.Recipient.Type,
+			Hashed:   true,
+			Salt:     salt,
+		}
+	}
+	computed, err := client.CertificateHash(issued)
+	switch {
+	case err != nil:
+		add("integrity", FAILED, err.Error())
+	case cert.Hash == "":
+		add("integrity", FAILED, "certificate has no hash")
+	case !issued.Recipient.Hashed:
+		add("integrity", FAILED, "recipient is in clear text, the recipient salt is needed (-salt)")
+	case computed != cert.Hash:
+		add("integrity", FAILED, "stored hash "+cert.Hash+", computed "+computed)
+	default:
+		add("integrity", PASSED, cert.Hash)
+	}
+
+	// 2. Merkle proof and issuer signature
+	// ------------------------------------
+	if proof == nil {
+		add("merkle", SKIPPED, "no Merkle proof")
+		add("signature", SKIPPED, "no Merkle proof")
+	} else {
+		err = merkleproof.Check(proof, computed)
+		if err != nil {
+			add("merkle", FAILED, err.Error())
+		} else {
+			add("merkle", PASSED, "root "+proof.MerkleRoot)
+		}
+
+		if len(keys) == 0 {
+			add("signature", SKIPPED, "no issuer key bundle")
+		} else if err = merkleproof.CheckSignature(proof, keys, cert.Badge.Issuer.Id); err != nil {
+			add("signature", FAILED, err.Error())
+		} else {
+			add("signature", PASSED, "Merkle root signed by a key of "+cert.Badge.Issuer.Id)
+		}
+	}
+
+	// 3. Recipient
+	// ------------
+	switch {
+	case email == "":
+		add("recipient", SKIPPED, "no recipient email")
+	case cert.SubjectErased:
+		add("recipient", FAILED, "recipient data was erased")
+	case !cert.Recipient.Hashed && cert.Recipient.Identity == email,
+		cert.Recipient.Hashed && client.HashRecipient(email, cert.Recipient.Salt) == cert.Recipient.Identity:
+		add("recipient", PASSED, email)
+	default:
+		add("recipient", FAILED, email+" doesn't match the recipient identity")
+	}
+
+	// 4. Revocation
+	// -------------
+	revoked, reason := cert.Revoked, cert.RevocationReason
+	if revocationList != nil {
+		for _, assertion := range revocationList.RevokedAssertions {
+			if assertion.Id == cert.Id {
+				revoked, reason = true, assertion.RevocationReason
+			}
+		}
+	}
+	switch {
+	case revoked:
+		add("revocation", FAILED, strings.TrimSpace("revoked "+reason))
+	case revocationList == nil:
+		add("revocation", PASSED, "not flagged revoked (no revocation list)")
+	default:
+		add("revocation", PASSED, "not revoked")
+	}
+
+	// 5. Expiry
+	// ---------
+	if cert.Expires == "" {
+		add("expiry", PASSED, "no expiry")
+	} else if expires, err := time.Parse(time.RFC3339, cert.Expires); err != nil {
+		add("expiry", FAILED, "invalid expiry date "+cert.Expires)
+	} else if !now.Before(expires) {
+		add("expiry", FAILED, "expired on "+cert.Expires)
+	} else {
+		add("expiry", PASSED, "expires on "+cert.Expires)
+	}
+
+	report.Verdict = VALID
+	for _, check := range report.Checks {
+		switch {
+		case check.Status == FAILED:
+			report.Verdict = INVALID
+		case check.Status != PASSED && (check.Name == "merkle" || check.Name == "signature") && report.Verdict == VALID:
+			report.Verdict = INCONCLUSIVE
+		}
+	}
+	report.Valid = report.Verdict == VALID
+	return report
+}
+
+// readKeys reads the ECDSA keys of a PEM bundle of certificates and public
+// keys, by issuer
+func readKeys(path string) (merkleproof.KeyBundle, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := merkleproof.KeyBundle{}
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+
+		var pub interface{}
+		var issuerIDs []string
+		switch block.Type {
+		case "CERTIFICATE":
+			cert, err := x509.ParseCertificate(block.Bytes)
+			if err != nil {
+				return nil, errors.New(path + ": " + err.Error())
+			}
+			pub = cert.PublicKey
+			issuerIDs, err = certificateIssuerIDs(cert)
+			if err != nil {
+				return nil, errors.New(path + ": " + err.Error())
+			}
+		case "PUBLIC KEY":
+			pub, err = x509.ParsePKIXPublicKey(block.Bytes)
+			if err != nil {
+				return nil, errors.New(path + ": " + err.Error())
+			}
+			if block.Headers["Issuer"] == "" {
+				return nil, errors.New(path + ": public keys need an \"Issuer: <id>\" header")
+			}
+			issuerIDs = []string{block.Headers["Issuer"]}
+		default:
+			continue
+		}
+
+		key, ok := pub.(*ecdsa.PublicKey)
+		if !ok {
+			return nil, errors.New(path + ": only ECDSA keys are supported")
+		}
+		for _, issuerID := range issuerIDs {
+			keys.Add(issuerID, key)
+		}
+	}
+
+	if len(keys) == 0 {
+		return nil, errors.New(path + ": no PEM certificate or public key")
+	}
+	return keys, nil
+}
+
+// certificateIssuerIDs returns the issuer IDs of a certificate: its Fabric
+// CA email attribute, email SANs and common name
+func certificateIssuerIDs(cert *x509.Certificate) ([]string, error) {
+	var issuerIDs []string
+	seen := make(map[string]bool)
+	add := func(issuerID string) {
+		if issuerID != "" && !seen[issuerID] {
+			seen[issuerID] = true
+			issuerIDs = append(issuerIDs, issuerID)
+		}
+	}
+
+	attrs, err := attrmgr.New().GetAttributesFromCert(cert)
+	if err != nil {
+		return nil, err
+	}
+	if email, ok, _ := attrs.Value("email"); ok {
+		add(email)
+	}
+	for _, email := range cert.EmailAddresses {
+		add(email)
+	}
+	add(cert.Subject.CommonName)
+	return issuerIDs, nil
+}
+
+func readJSON(path string, v interface{}) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	err = json.Unmarshal(data, v)
+	if err != nil {
+		return errors.New(path + ": " + err.Error())
+	}
+	return nil
+}
+
+func printReport(report Report) {
+	fmt.Printf("Certificate %s\n", report.Id)
+	for _, check := range report.Checks {
+		fmt.Printf("  %-10s %-7s %s\n", check.Name, check.Status, check.Message)
+	}
+	fmt.Println(report.Verdict)
+}
+
+func exitOnError(err error) {
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "ccverify:", err)
+		os.Exit(2)
+	}
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/certificates/go/client"
+	"github.com/certificates/go/merkleproof"
+	"github.com/certificates/go/mspidentity"
+)
+
+func TestVerify(t *testing.T) {
+	cert := client.Certificate{
+		Id:        "cert:issuer1@org1.example.com/data-science-1",
+		IssuedOn:  "2018-10-17T07:29:47Z",
+		Badge:     client.Badge{Issuer: client.Issuer{Id: "issuer1@org1.example.com"}},
+		Recipient: client.Recipient{Identity: client.HashRecipient("student@example.com", "salt"), Type: "email", Hashed: true, Salt: "salt"},
+	}
+	var err error
+	cert.Hash, err = client.CertificateHash(cert)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	other, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	proofs, err := merkleproof.Build([]string{cert.Hash})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := merkleproof.Sign(proofs, key); err != nil {
+		t.Fatal(err)
+	}
+	keys := merkleproof.KeyBundle{}
+	keys.Add("issuer1@org1.example.com", &key.PublicKey)
+	keys.Add("issuer2@org2.example.com", &other.PublicKey)
+	otherIssuer := merkleproof.KeyBundle{}
+	otherIssuer.Add("issuer2@org2.example.com", &key.PublicKey)
+
+	altered := cert
+	altered.IssuedOn = "2019-10-17T07:29:47Z"
+
+	tests := []struct {
+		name    string
+		cert    client.Certificate
+		proof   *merkleproof.Proof
+		keys    merkleproof.KeyBundle
+		verdict string
+	}{
+		{"signed", cert, proofs[0], keys, VALID},
+		{"no proof", cert, nil, keys, INCONCLUSIVE},
+		{"no keys", cert, proofs[0], nil, INCONCLUSIVE},
+		{"other issuer key", cert, proofs[0], merkleproof.KeyBundle{"issuer1@org1.example.com": {&other.PublicKey}}, INVALID},
+		// the signing key belongs to issuer2 in the bundle
+		{"signed by another issuer", cert, proofs[0], otherIssuer, INVALID},
+		{"altered certificate", altered, proofs[0], keys, INVALID},
+		{"altered certificate, no proof", altered, nil, nil, INVALID},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			report := verify(test.cert, "", test.proof, test.keys, "student@example.com", nil, time.Now())
+			if report.Verdict != test.verdict || report.Valid != (test.verdict == VALID) {
+				t.Errorf("expected %s, got %s: %+v", test.verdict, report.Verdict, report.Checks)
+			}
+		})
+	}
+}
+
+func TestReadKeys(t *testing.T) {
+	ca, err := mspidentity.NewCA("Org1MSP", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	identity, err := ca.NewIdentity("issuer1", map[string]string{"email": "issuer1@org1.example.com"}, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicKeyPEM := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Headers: map[string]string{"Issuer": "issuer2@org2.example.com"}, Bytes: der})
+
+	dir, err := ioutil.TempDir("", "ccverify")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "issuers.pem")
+	if err := ioutil.WriteFile(path, append(identity.CertPEM, publicKeyPEM...), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	keys, err := readKeys(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys["issuer1@org1.example.com"]) != 1 || len(keys["issuer1"]) != 1 || len(keys["issuer2@org2.example.com"]) != 1 {
+		t.Errorf("expected the keys of issuer1 (email and common name) and issuer2, got %v", keys)
+	}
+
+	// public keys without issuer
+	publicKeyPEM = pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+	if err := ioutil.WriteFile(path, publicKeyPEM, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readKeys(path); err == nil {
+		t.Error("expected an error for a public key without issuer")
+	}
+}
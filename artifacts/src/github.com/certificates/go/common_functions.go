@@ -1,9 +1,11 @@
 package main
 
 import (
-	"encoding/json"
 	"errors"
 
+	"github.com/certificates/go/canonicaljson"
+	"github.com/certificates/go/ccerrors"
+	"github.com/certificates/go/events"
 	"github.com/hyperledger/fabric/core/chaincode/shim"
 	pb "github.com/hyperledger/fabric/protos/peer"
 )
@@ -13,15 +15,12 @@ func (t *SimpleChaincode) initLedger(stub shim.ChaincodeStubInterface) pb.Respon
 	// Get issuer-list state from the ledger
 	KeyValBytes, err := stub.GetState(ISSUER_LIST)
 	if err != nil {
-		jsonResp := "{\"Error\":\"Failed to get state for issuer-list \"}"
-		return shim.Error(jsonResp)
+		return errorResponse(ccerrors.New(ccerrors.Internal, "Failed to get state for issuer-list"))
 	}
 
 	if KeyValBytes != nil {
 		// if issuer-list exists do nothing
-		jsonResp := "{\"Error\":\"issuer-list is already created, aborting!\"}"
-		logger.Infof(jsonResp)
-		return shim.Error(jsonResp)
+		return errorResponse(ccerrors.New(ccerrors.AlreadyExists, "issuer-list is already created, aborting!"))
 	}
 
 	// issuer-list doesn't exist, creating
@@ -29,30 +28,35 @@ func (t *SimpleChaincode) initLedger(stub shim.ChaincodeStubInterface) pb.Respon
 	issuerList := IssuerList{}
 
 	// Marshal issuerList
-	out, marshalErr := json.Marshal(issuerList)
+	out, marshalErr := canonicaljson.Marshal(issuerList)
 
 	if marshalErr != nil {
 		// error marshaling list
-		return shim.Error(marshalErr.Error())
+		return errorResponse(marshalErr)
 	}
 
 	// Write the state into the ledger
 	err = stub.PutState(ISSUER_LIST, []byte(out))
 	if err != nil {
-		return shim.Error(err.Error())
+		return errorResponse(err)
 	}
 
-	returnMessage := "Successfully created Issuer List"
-	logger.Infof(returnMessage)
-	return shim.Success([]byte(returnMessage))
+	err = emitEvent(stub, events.LedgerInitialized, events.LedgerInitializedPayload{})
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	logger.Infof("Successfully created Issuer List")
+	return mutationResponse(stub, []string{ISSUER_LIST}, nil, issuerList)
 
 }
 
-// Marshal an elem and upload to ledger using the provided key string
+// Marshal an elem (canonical JSON, RFC 8785) and upload to ledger using the
+// provided key string
 func marshalAndPutState(stub shim.ChaincodeStubInterface, elem interface{}, key string) error {
 
 	// Marshal
-	out, marshalErr := json.Marshal(elem)
+	out, marshalErr := canonicaljson.Marshal(elem)
 	if marshalErr != nil {
 		// error marshaling
 		return errors.New(marshalErr.Error())
@@ -69,36 +73,71 @@ func marshalAndPutState(stub shim.ChaincodeStubInterface, elem interface{}, key
 	return nil
 }
 
-// Function that retrieves an element from ledger using the provided key
-//
-// It retrieves a map[string] element, that can be converted to the desired struct
-// using the function FillStruct
-func getStructFromLedger(stub shim.ChaincodeStubInterface, key string) (map[string]interface{}, error) {
+// Marshal an elem (canonical JSON) and upload to a private data collection
+// using the provided key string
+func marshalAndPutPrivateData(stub shim.ChaincodeStubInterface, collection string, elem interface{}, key string) error {
+
+	// Marshal
+	out, marshalErr := canonicaljson.Marshal(elem)
+	if marshalErr != nil {
+		// error marshaling
+		return errors.New(marshalErr.Error())
+	}
+
+	// Write the private data into the collection (KEY, unique)
+	err := stub.PutPrivateData(collection, key, out)
+	if err != nil {
+		return errors.New(err.Error())
+	}
+
+	logger.Infof("%T created in collection %s with key: %s!", elem, collection, key)
 
-	var element map[string]interface{}
+	return nil
+}
 
-	// Get state from the ledger
-	elementBytes, err := stub.GetState(key)
+// Response of every function that changes the ledger
+type MutationResult struct {
+	Created   []string    `json:"created"`
+	Updated   []string    `json:"updated"`
+	Object    interface{} `json:"object,omitempty"`
+	TxID      string      `json:"txId"`
+	Timestamp string      `json:"timestamp"`
+}
+
+// Function that returns a success response with a MutationResult: the
+// created and updated keys, the resulting object, txID and timestamp
+func mutationResponse(stub shim.ChaincodeStubInterface, created, updated []string, object interface{}) pb.Response {
+	timestamp, err := getTxTimestamp(stub)
 	if err != nil {
-		jsonResp := "Failed to get state for " + key
-		logger.Errorf(jsonResp)
-		return nil, errors.New(jsonResp)
+		return errorResponse(err)
 	}
 
-	if elementBytes == nil {
-		// element doesn't exists (return empty map[])
-		jsonResp := key + " doesn't exist"
-		logger.Warningf(jsonResp)
-		return make(map[string]interface{}), nil
+	if created == nil {
+		created = []string{}
+	}
+	if updated == nil {
+		updated = []string{}
 	}
 
-	json.Unmarshal(elementBytes, &element)
+	result := MutationResult{
+		Created:   created,
+		Updated:   updated,
+		Object:    object,
+		TxID:      stub.GetTxID(),
+		Timestamp: timestamp,
+	}
 
-	return element, nil
+	out, err := canonicaljson.Marshal(result)
+	if err != nil {
+		return errorResponse(err)
+	}
+	return shim.Success(out)
 }
 
-// Function that convert map[string] to struct
-func FillStruct(m map[string]interface{}, s interface{}) {
-	j, _ := json.Marshal(m)
-	json.Unmarshal(j, s)
+// Function that returns an error response with the JSON error payload
+// (see ccerrors). Errors without code are returned as INTERNAL.
+func errorResponse(err error) pb.Response {
+	chaincodeErr := ccerrors.From(err)
+	logger.Errorf(chaincodeErr.Error())
+	return shim.Error(chaincodeErr.JSON())
 }
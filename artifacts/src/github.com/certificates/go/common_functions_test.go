@@ -0,0 +1,22 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/certificates/go/ccerrors"
+	"github.com/certificates/go/cctest"
+)
+
+func TestInitLedger(t *testing.T) {
+	f := newLedger(t)
+	f.stub.Load(nil, nil)
+
+	var mutation MutationResult
+	f.stub.Run(t, []cctest.Case{
+		{Name: "empty ledger", Identity: f.issuer1, Function: "initLedger",
+			Check: cctest.Decode(&mutation, func() error {
+				return expect(len(mutation.Created) == 1 && mutation.Created[0] == ISSUER_LIST, "issuer-list created", mutation.Created)
+			})},
+		{Name: "already created", Identity: f.issuer1, Function: "initLedger", Code: ccerrors.AlreadyExists},
+	})
+}
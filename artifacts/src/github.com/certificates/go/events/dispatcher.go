@@ -0,0 +1,143 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Dispatcher decodes event payloads and calls the handler registered for
+// their type. Events without handler are ignored.
+type Dispatcher struct {
+	handlers map[Type]func(*Envelope) error
+}
+
+// NewDispatcher creates an empty Dispatcher
+func NewDispatcher() *Dispatcher {
+	return &Dispatcher{handlers: make(map[Type]func(*Envelope) error)}
+}
+
+// Handle registers a raw handler for an event type
+func (d *Dispatcher) Handle(eventType Type, handler func(*Envelope) error) {
+	d.handlers[eventType] = handler
+}
+
+// OnLedgerInitialized registers a handler for LedgerInitialized events
+func (d *Dispatcher) OnLedgerInitialized(handler func(*Envelope, LedgerInitializedPayload) error) {
+	d.Handle(LedgerInitialized, func(e *Envelope) error {
+		var payload LedgerInitializedPayload
+		if err := decodePayload(e, &payload); err != nil {
+			return err
+		}
+		return handler(e, payload)
+	})
+}
+
+// OnBadgeIssued registers a handler for BadgeIssued events
+func (d *Dispatcher) OnBadgeIssued(handler func(*Envelope, BadgeIssuedPayload) error) {
+	d.Handle(BadgeIssued, func(e *Envelope) error {
+		var payload BadgeIssuedPayload
+		if err := decodePayload(e, &payload); err != nil {
+			return err
+		}
+		return handler(e, payload)
+	})
+}
+
+// OnCertificateIssued registers a handler for CertificateIssued events
+func (d *Dispatcher) OnCertificateIssued(handler func(*Envelope, CertificateIssuedPayload) error) {
+	d.Handle(CertificateIssued, func(e *Envelope) error {
+		var payload CertificateIssuedPayload
+		if err := decodePayload(e, &payload); err != nil {
+			return err
+		}
+		return handler(e, payload)
+	})
+}
+
+// OnRecipientErased registers a handler for RecipientErased events
+func (d *Dispatcher) OnRecipientErased(handler func(*Envelope, RecipientErasedPayload) error) {
+	d.Handle(RecipientErased, func(e *Envelope) error {
+		var payload RecipientErasedPayload
+		if err := decodePayload(e, &payload); err != nil {
+			return err
+		}
+		return handler(e, payload)
+	})
+}
+
+// OnCertificateRevoked registers a handler for CertificateRevoked events
+func (d *Dispatcher) OnCertificateRevoked(handler func(*Envelope, CertificateRevokedPayload) error) {
+	d.Handle(CertificateRevoked, func(e *Envelope) error {
+		var payload CertificateRevokedPayload
+		if err := decodePayload(e, &payload); err != nil {
+			return err
+		}
+		return handler(e, payload)
+	})
+}
+
+// OnRecipientPepperSet registers a handler for RecipientPepperSet events
+func (d *Dispatcher) OnRecipientPepperSet(handler func(*Envelope, RecipientPepperSetPayload) error) {
+	d.Handle(RecipientPepperSet, func(e *Envelope) error {
+		var payload RecipientPepperSetPayload
+		if err := decodePayload(e, &payload); err != nil {
+			return err
+		}
+		return handler(e, payload)
+	})
+}
+
+// OnStateImported registers a handler for StateImported events
+func (d *Dispatcher) OnStateImported(handler func(*Envelope, StateImportedPayload) error) {
+	d.Handle(StateImported, func(e *Envelope) error {
+		var payload StateImportedPayload
+		if err := decodePayload(e, &payload); err != nil {
+			return err
+		}
+		return handler(e, payload)
+	})
+}
+
+// OnCertificatesMigrated registers a handler for CertificatesMigrated events
+func (d *Dispatcher) OnCertificatesMigrated(handler func(*Envelope, CertificatesMigratedPayload) error) {
+	d.Handle(CertificatesMigrated, func(e *Envelope) error {
+		var payload CertificatesMigratedPayload
+		if err := decodePayload(e, &payload); err != nil {
+			return err
+		}
+		return handler(e, payload)
+	})
+}
+
+// OnRecipientDataRenewed registers a handler for RecipientDataRenewed events
+func (d *Dispatcher) OnRecipientDataRenewed(handler func(*Envelope, RecipientDataRenewedPayload) error) {
+	d.Handle(RecipientDataRenewed, func(e *Envelope) error {
+		var payload RecipientDataRenewedPayload
+		if err := decodePayload(e, &payload); err != nil {
+			return err
+		}
+		return handler(e, payload)
+	})
+}
+
+// Dispatch decodes a chaincode event payload and calls its handler
+func (d *Dispatcher) Dispatch(data []byte) error {
+	envelope, err := Decode(data)
+	if err != nil {
+		return err
+	}
+
+	handler, ok := d.handlers[envelope.Type]
+	if !ok {
+		return nil
+	}
+	return handler(envelope)
+}
+
+func decodePayload(e *Envelope, payload interface{}) error {
+	err := json.Unmarshal(e.Payload, payload)
+	if err != nil {
+		return fmt.Errorf("decoding %s payload: %v", e.Type, err)
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+// Package events contains the chaincode events emitted by the certificates
+// chaincode and a dispatcher to consume them off-chain.
+//
+// Every state-changing function emits one event (Fabric keeps only the last
+// event set in a transaction). The event name is the event Type and the
+// payload is a versioned Envelope in JSON.
+//
+// Bulk functions (importState, migrateCertificates, renewRecipientData)
+// write many keys in a transaction, so they emit a single bulk event
+// listing the written keys instead of one event per badge or certificate.
+// Consumers keeping a view of the ledger must read those keys again.
+package events
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Version of the event envelope, increased on breaking payload changes
+const Version = 1
+
+// Type of event, also used as the chaincode event name
+type Type string
+
+const (
+	LedgerInitialized  Type = "LedgerInitialized"
+	BadgeIssued        Type = "BadgeIssued"
+	CertificateIssued  Type = "CertificateIssued"
+	RecipientErased    Type = "RecipientErased"
+	CertificateRevoked Type = "CertificateRevoked"
+	RecipientPepperSet Type = "RecipientPepperSet"
+
+	// bulk events
+	StateImported        Type = "StateImported"
+	CertificatesMigrated Type = "CertificatesMigrated"
+	RecipientDataRenewed Type = "RecipientDataRenewed"
+)
+
+// Envelope wraps every event payload
+type Envelope struct {
+	Version   int             `json:"version"`
+	Type      Type            `json:"type"`
+	TxID      string          `json:"txId"`
+	Timestamp string          `json:"timestamp"`
+	Payload   json.RawMessage `json:"payload"`
+}
+
+// Payloads
+
+type LedgerInitializedPayload struct {
+}
+
+type BadgeIssuedPayload struct {
+	BadgeID       string `json:"badgeId"`
+	IssuerID      string `json:"issuerId"`
+	IssuerCreated bool   `json:"issuerCreated"`
+}
+
+type CertificateIssuedPayload struct {
+	CertID   string `json:"certId"`
+	BadgeID  string `json:"badgeId"`
+	IssuerID string `json:"issuerId"`
+	IssuedOn string `json:"issuedOn"`
+}
+
+type RecipientErasedPayload struct {
+	CertIDs []string `json:"certIds"`
+}
+
+type CertificateRevokedPayload struct {
+	CertID   string `json:"certId"`
+	BadgeID  string `json:"badgeId"`
+	IssuerID string `json:"issuerId"`
+	Reason   string `json:"reason"`
+}
+
+// The pepper value itself is never part of the event
+type RecipientPepperSetPayload struct {
+	Collection string `json:"collection"`
+}
+
+// Keys written by an importState batch (badges, certificates, issuers and
+// indexes), existing keys with the same value are not listed
+type StateImportedPayload struct {
+	Keys []string `json:"keys"`
+}
+
+// Certificates rewritten by a migrateCertificates page
+type CertificatesMigratedPayload struct {
+	CertIDs []string `json:"certIds"`
+}
+
+// Certificates whose recipient private data was rewritten by a
+// renewRecipientData page
+type RecipientDataRenewedPayload struct {
+	CertIDs []string `json:"certIds"`
+}
+
+// ErrUnsupportedVersion is returned when decoding an envelope with a version
+// this package doesn't know
+var ErrUnsupportedVersion = errors.New("unsupported event version")
+
+// New marshals an event envelope for the given type and payload
+func New(eventType Type, txID, timestamp string, payload interface{}) ([]byte, error) {
+	payloadBytes, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	envelope := Envelope{
+		Version:   Version,
+		Type:      eventType,
+		TxID:      txID,
+		Timestamp: timestamp,
+		Payload:   payloadBytes,
+	}
+	return json.Marshal(envelope)
+}
+
+// Decode unmarshals an event envelope, checking its version
+func Decode(data []byte) (*Envelope, error) {
+	var envelope Envelope
+	err := json.Unmarshal(data, &envelope)
+	if err != nil {
+		return nil, fmt.Errorf("decoding event envelope: %v", err)
+	}
+	if envelope.Version != Version {
+		return nil, fmt.Errorf("%v: %d", ErrUnsupportedVersion, envelope.Version)
+	}
+	if len(envelope.Type) == 0 {
+		return nil, errors.New("event envelope without type")
+	}
+	return &envelope, nil
+}
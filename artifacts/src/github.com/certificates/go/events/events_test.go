@@ -0,0 +1,72 @@
+package events
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+// TestRoundTrip dispatches every testdata event to its typed handler and
+// marshals the decoded payload back, it must give the same JSON
+func TestRoundTrip(t *testing.T) {
+	var payload interface{}
+	d := NewDispatcher()
+	d.OnLedgerInitialized(func(e *Envelope, p LedgerInitializedPayload) error { payload = p; return nil })
+	d.OnBadgeIssued(func(e *Envelope, p BadgeIssuedPayload) error { payload = p; return nil })
+	d.OnCertificateIssued(func(e *Envelope, p CertificateIssuedPayload) error { payload = p; return nil })
+	d.OnRecipientErased(func(e *Envelope, p RecipientErasedPayload) error { payload = p; return nil })
+	d.OnCertificateRevoked(func(e *Envelope, p CertificateRevokedPayload) error { payload = p; return nil })
+	d.OnRecipientPepperSet(func(e *Envelope, p RecipientPepperSetPayload) error { payload = p; return nil })
+	d.OnStateImported(func(e *Envelope, p StateImportedPayload) error { payload = p; return nil })
+	d.OnCertificatesMigrated(func(e *Envelope, p CertificatesMigratedPayload) error { payload = p; return nil })
+	d.OnRecipientDataRenewed(func(e *Envelope, p RecipientDataRenewedPayload) error { payload = p; return nil })
+
+	for _, eventType := range []Type{
+		LedgerInitialized, BadgeIssued, CertificateIssued, RecipientErased,
+		CertificateRevoked, RecipientPepperSet, StateImported, CertificatesMigrated, RecipientDataRenewed,
+	} {
+		t.Run(string(eventType), func(t *testing.T) {
+			data, err := ioutil.ReadFile(filepath.Join("testdata", string(eventType)+".json"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			data = bytes.TrimSpace(data)
+
+			payload = nil
+			if err := d.Dispatch(data); err != nil {
+				t.Fatal(err)
+			}
+			if payload == nil {
+				t.Fatal("expected the typed handler to be called")
+			}
+
+			envelope, err := Decode(data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if envelope.Type != eventType {
+				t.Errorf("expected type %s, got %s", eventType, envelope.Type)
+			}
+			out, err := New(envelope.Type, envelope.TxID, envelope.Timestamp, payload)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(out, data) {
+				t.Errorf("expected\n%s\ngot\n%s", data, out)
+			}
+		})
+	}
+}
+
+func TestDecodeInvalid(t *testing.T) {
+	for name, data := range map[string]string{
+		"not JSON":        `{`,
+		"unknown version": `{"version":2,"type":"BadgeIssued","payload":{}}`,
+		"no type":         `{"version":1,"payload":{}}`,
+	} {
+		if _, err := Decode([]byte(data)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
@@ -0,0 +1,386 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/certificates/go/canonicaljson"
+	"github.com/certificates/go/ccerrors"
+	"github.com/certificates/go/events"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	pb "github.com/hyperledger/fabric/protos/peer"
+)
+
+// World state export and import (backup, channel migration).
+//
+// exportState pages over every key of the chaincode, one key space after the
+// other: simple keys (issuer-list, issuers, badges and certificates), then
+// the composite key indexes. Each page is an import batch: importState
+// checks its checksum and replays it in a single transaction.
+//
+// Private data (recipient details) stays in the organizations collections
+// and is not exported.
+//
+// An admin imports the records of its own organization only: the issuer
+// owning each record (the badge issuer of badges, certificates and their
+// indexes) must be registered in the caller's org, or not registered yet,
+// and the exported endorsement policy must be the caller's org. The policy
+// is rebuilt from the caller's org, like issueBadge and issueCertificate do.
+
+// maximum number of records of an import batch
+const MAX_IMPORT_BATCH = MAX_PAGE_SIZE
+
+// Key spaces of the export, in order. "" is the simple keys.
+var STATE_KEY_SPACES = []string{"", BADGE_TERM_INDEX, DOC_HASH_INDEX, STATS_DELTA_INDEX, BADGE_VERSION_INDEX}
+
+// A page of exportState and a batch of importState
+type StateBatch struct {
+	Records []StateRecord `json:"records"`
+	// sha256$<hex> of the canonical JSON (RFC 8785) of the records
+	Checksum string `json:"checksum"`
+	// Bookmark of the next page, empty after the last one (export only)
+	Bookmark string `json:"bookmark,omitempty"`
+}
+
+type StateRecord struct {
+	Key   string `json:"key"`
+	Value []byte `json:"value"`
+	// Key-level endorsement policy of issuers, badges and certificates (see
+	// utils_endorsement.go)
+	Policy []byte `json:"policy,omitempty"`
+}
+
+// Query that exports a page of the world state. Admins only.
+//
+// Arguments: 1) pageSize, 2) bookmark (empty for the first page). A page
+// covers a single key space, so it may hold less than pageSize records
+// while the bookmark is not empty.
+func (t *SimpleChaincode) exportState(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+
+	if len(args) != 2 {
+		return errorResponse(ccerrors.New(ccerrors.InvalidArgument, "Incorrect number of arguments. Expecting 2: 1) pageSize, 2) bookmark"))
+	}
+
+	err := checkUserAttr(stub, "admin", "true")
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	pageSize, bookmark, err := parsePaginationArgs(args[0], args[1])
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	// 1. Find the key space of the page
+	// ---------------------------------
+	space, spaceBookmark, err := parseExportBookmark(bookmark)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	// 2. Read the page
+	// ----------------
+	var resultsIterator shim.StateQueryIteratorInterface
+	var metadata *pb.QueryResponseMetadata
+	if STATE_KEY_SPACES[space] == "" {
+		resultsIterator, metadata, err = stub.GetStateByRangeWithPagination("", "", pageSize, spaceBookmark)
+	} else {
+		resultsIterator, metadata, err = stub.GetStateByPartialCompositeKeyWithPagination(STATE_KEY_SPACES[space], []string{}, pageSize, spaceBookmark)
+	}
+	if err != nil {
+		return errorResponse(ccerrors.New(ccerrors.Internal, "Failed to export key space '"+STATE_KEY_SPACES[space]+"': "+err.Error()))
+	}
+
+	page, err := collectPage(resultsIterator, metadata)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	batch := StateBatch{Records: []StateRecord{}}
+	for _, record := range page.Records {
+		policy, err := stub.GetStateValidationParameter(record.Key)
+		if err != nil {
+			return errorResponse(ccerrors.New(ccerrors.Internal, "Failed to get endorsement policy for "+record.Key+": "+err.Error()))
+		}
+		batch.Records = append(batch.Records, StateRecord{Key: record.Key, Value: record.Record, Policy: policy})
+	}
+
+	batch.Checksum, err = hashJSON(batch.Records)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	// 3. Bookmark of the next page (next key space when this one is done)
+	// --------------------------------------------------------------------
+	if page.Bookmark != "" {
+		batch.Bookmark = strconv.Itoa(space) + ":" + page.Bookmark
+	} else if space+1 < len(STATE_KEY_SPACES) {
+		batch.Bookmark = strconv.Itoa(space+1) + ":"
+	}
+
+	out, err := canonicaljson.Marshal(batch)
+	if err != nil {
+		return errorResponse(err)
+	}
+	return shim.Success(out)
+}
+
+// Function that splits an export bookmark (<key space>:<range bookmark>)
+func parseExportBookmark(bookmark string) (int, string, error) {
+	if bookmark == "" {
+		return 0, "", nil
+	}
+
+	parts := strings.SplitN(bookmark, ":", 2)
+	space, err := strconv.Atoi(parts[0])
+	if len(parts) != 2 || err != nil || space < 0 || space >= len(STATE_KEY_SPACES) {
+		return 0, "", ccerrors.New(ccerrors.InvalidArgument, "Invalid export bookmark")
+	}
+	return space, parts[1], nil
+}
+
+// Function that replays a batch of exportState. Admins only.
+//
+// Argument: 1) StateBatch (JSON). Keys that already exist with the same
+// value are left untouched, keys with another value are a CONFLICT.
+// Records owned by an issuer of another organization are FORBIDDEN.
+func (t *SimpleChaincode) importState(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+
+	logger.Infof("Action: import State")
+
+	if len(args) != 1 {
+		return errorResponse(ccerrors.New(ccerrors.InvalidArgument, "Incorrect number of arguments. Expecting 1: batch (JSON)"))
+	}
+
+	err := checkUserAttr(stub, "admin", "true")
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	mspID, err := getUserMSPID(stub)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	// 1. Decode the batch and check its checksum
+	// ------------------------------------------
+	var batch StateBatch
+	decoder := json.NewDecoder(bytes.NewReader([]byte(args[0])))
+	decoder.DisallowUnknownFields()
+	err = decoder.Decode(&batch)
+	if err != nil {
+		return errorResponse(ccerrors.New(ccerrors.InvalidArgument, "Invalid batch: "+err.Error()))
+	}
+
+	if len(batch.Records) == 0 || len(batch.Records) > MAX_IMPORT_BATCH {
+		return errorResponse(ccerrors.New(ccerrors.InvalidArgument, "A batch must have between 1 and "+strconv.Itoa(MAX_IMPORT_BATCH)+" records"))
+	}
+
+	checksum, err := hashJSON(batch.Records)
+	if err != nil {
+		return errorResponse(err)
+	}
+	if checksum != batch.Checksum {
+		return errorResponse(ccerrors.New(ccerrors.InvalidArgument, "Batch checksum mismatch: expected "+batch.Checksum+", computed "+checksum))
+	}
+
+	// 2. Validate and write the records
+	// ---------------------------------
+	// reads see the records written before them, so a key repeated in the
+	// batch is skipped or a CONFLICT like an existing one
+	var created []string
+	for _, record := range batch.Records {
+		owner, err := validateStateRecord(stub, record)
+		if err != nil {
+			return errorResponse(err)
+		}
+
+		err = checkRecordOwner(stub, record, owner, mspID)
+		if err != nil {
+			return errorResponse(err)
+		}
+
+		existing, err := stub.GetState(record.Key)
+		if err != nil {
+			return errorResponse(ccerrors.New(ccerrors.Internal, "Failed to get state for "+record.Key))
+		}
+		if existing != nil {
+			if !bytes.Equal(existing, record.Value) {
+				return errorResponse(ccerrors.New(ccerrors.Conflict, record.Key+" already exists with another value"))
+			}
+			continue
+		}
+
+		err = stub.PutState(record.Key, record.Value)
+		if err != nil {
+			return errorResponse(err)
+		}
+		if isEndorsedKey(record.Key) {
+			err = setIssuerEndorsementPolicy(stub, record.Key)
+			if err != nil {
+				return errorResponse(err)
+			}
+		}
+		created = append(created, record.Key)
+	}
+
+	// one bulk event for the batch (see package events)
+	err = emitEvent(stub, events.StateImported, events.StateImportedPayload{Keys: created})
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	logger.Infof("Imported %d of %d records", len(created), len(batch.Records))
+	return mutationResponse(stub, created, nil, nil)
+}
+
+// Function that checks that a record belongs to a known key space and that
+// its value decodes as the type stored under its key. It returns the ID of
+// the issuer owning the record, empty for the issuer list.
+func validateStateRecord(stub shim.ChaincodeStubInterface, record StateRecord) (string, error) {
+	if len(record.Value) == 0 {
+		return "", ccerrors.New(ccerrors.InvalidArgument, "Empty value for "+record.Key)
+	}
+
+	// composite keys start with U+0000
+	if strings.HasPrefix(record.Key, "\x00") {
+		objectType, attributes, err := stub.SplitCompositeKey(record.Key)
+		if err != nil || len(attributes) == 0 {
+			return "", ccerrors.New(ccerrors.InvalidArgument, "Invalid composite key "+strconv.Quote(record.Key))
+		}
+		switch objectType {
+		case BADGE_VERSION_INDEX:
+			// badge-version~badgeID~version
+			var badge Badge
+			err = decodeState(record.Key, record.Value, &badge)
+			if err != nil {
+				return "", err
+			}
+			if badge.Id != attributes[0] {
+				return "", ccerrors.New(ccerrors.InvalidArgument, "Badge version of "+badge.Id+" stored under "+attributes[0])
+			}
+			return badge.Issuer.Id, nil
+		case BADGE_TERM_INDEX:
+			// badge-term~field~term~badgeID
+			var badge Badge
+			err = getState(stub, attributes[len(attributes)-1], &badge)
+			return badge.Issuer.Id, err
+		case DOC_HASH_INDEX:
+			// doc-hash~digest~certID
+			return getCertificateIssuer(stub, attributes[len(attributes)-1])
+		case STATS_DELTA_INDEX:
+			// stat-delta~issuerID~...
+			return attributes[0], nil
+		}
+		return "", ccerrors.New(ccerrors.InvalidArgument, "Unknown index '"+objectType+"'")
+	}
+
+	switch {
+	case record.Key == ISSUER_LIST:
+		return "", decodeState(record.Key, record.Value, &IssuerList{})
+	case strings.HasPrefix(record.Key, BADGE_PREFIX):
+		var badge Badge
+		err := decodeState(record.Key, record.Value, &badge)
+		if err != nil {
+			return "", err
+		}
+		if badge.Id != record.Key {
+			return "", ccerrors.New(ccerrors.InvalidArgument, "Badge "+badge.Id+" stored under "+record.Key)
+		}
+		return badge.Issuer.Id, nil
+	case strings.HasPrefix(record.Key, CERT_PREFIX):
+		certID, issuerID, err := decodeCertificateOwner(record.Key, record.Value)
+		if err != nil {
+			return "", err
+		}
+		if certID != record.Key {
+			return "", ccerrors.New(ccerrors.InvalidArgument, "Certificate "+certID+" stored under "+record.Key)
+		}
+		return issuerID, nil
+	default:
+		// issuers are stored under their email
+		var issuer Issuer
+		err := decodeState(record.Key, record.Value, &issuer)
+		if err != nil {
+			return "", err
+		}
+		if issuer.Email != record.Key {
+			return "", ccerrors.New(ccerrors.InvalidArgument, "Unknown key "+record.Key)
+		}
+		return issuer.Email, nil
+	}
+}
+
+// Function that checks that the caller's org owns an imported record: the
+// record can only carry a policy of the caller's org, and the issuer owning
+// it must be registered in the caller's org (or not registered yet)
+func checkRecordOwner(stub shim.ChaincodeStubInterface, record StateRecord, owner, mspID string) error {
+	if isEndorsedKey(record.Key) {
+		orgs, err := getPolicyOrgs(record.Policy)
+		if err != nil {
+			return ccerrors.New(ccerrors.InvalidArgument, "Invalid endorsement policy for "+record.Key+": "+err.Error())
+		}
+		if len(orgs) != 1 || orgs[0] != mspID {
+			return ccerrors.New(ccerrors.Forbidden, record.Key+" is not endorsed by "+mspID)
+		}
+	} else if len(record.Policy) > 0 {
+		return ccerrors.New(ccerrors.InvalidArgument, record.Key+" has no endorsement policy")
+	}
+
+	if owner == "" {
+		return nil
+	}
+
+	issuerPolicy, err := stub.GetStateValidationParameter(owner)
+	if err != nil {
+		return ccerrors.New(ccerrors.Internal, "Failed to get endorsement policy for "+owner+": "+err.Error())
+	}
+	if len(issuerPolicy) == 0 {
+		// not registered yet, imported for the caller's org
+		return nil
+	}
+	orgs, err := getPolicyOrgs(issuerPolicy)
+	if err != nil {
+		return ccerrors.New(ccerrors.DataCorrupted, "Invalid endorsement policy for "+owner+": "+err.Error())
+	}
+	if len(orgs) != 1 || orgs[0] != mspID {
+		return ccerrors.New(ccerrors.Forbidden, record.Key+" is owned by issuer "+owner+" of another organization")
+	}
+	return nil
+}
+
+// Function that tells if a key has a key-level endorsement policy: issuers,
+// badges and certificates (see setIssuerEndorsementPolicy)
+func isEndorsedKey(key string) bool {
+	return key != ISSUER_LIST && !strings.HasPrefix(key, "\x00")
+}
+
+// Function that returns the issuer of a certificate in the ledger, without
+// reading its badge version
+func getCertificateIssuer(stub shim.ChaincodeStubInterface, certID string) (string, error) {
+	stateBytes, err := stub.GetState(certID)
+	if err != nil {
+		return "", ccerrors.New(ccerrors.Internal, "Failed to get state for "+certID)
+	}
+	if stateBytes == nil {
+		return "", ccerrors.New(ccerrors.NotFound, certID+" doesn't exist")
+	}
+	_, issuerID, err := decodeCertificateOwner(certID, stateBytes)
+	return issuerID, err
+}
+
+// Function that decodes the ID and issuer of a certificate, in the stored
+// or the legacy form
+func decodeCertificateOwner(key string, stateBytes []byte) (string, string, error) {
+	if isBadgeReference(stateBytes) {
+		var stored StoredCertificate
+		err := decodeState(key, stateBytes, &stored)
+		return stored.Id, stored.Badge.Issuer.Id, err
+	}
+	// legacy certificate, with an embedded badge
+	var cert Certificate
+	err := decodeState(key, stateBytes, &cert)
+	return cert.Id, cert.Badge.Issuer.Id, err
+}
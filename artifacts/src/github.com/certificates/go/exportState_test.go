@@ -0,0 +1,119 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/certificates/go/ccerrors"
+	"github.com/certificates/go/cctest"
+	"github.com/certificates/go/events"
+	"github.com/hyperledger/fabric/core/chaincode/shim/ext/statebased"
+)
+
+func TestExportState(t *testing.T) {
+	f := newFixture(t)
+
+	var batch StateBatch
+	f.stub.Run(t, []cctest.Case{
+		{Name: "not admin", Identity: f.issuer1, Function: "exportState", Args: []string{"10", ""}, Code: ccerrors.Forbidden},
+		{Name: "invalid bookmark", Identity: f.admin, Function: "exportState", Args: []string{"10", "9:"}, Code: ccerrors.InvalidArgument},
+		{Name: "first page", Identity: f.admin, Function: "exportState", Args: []string{"2", ""},
+			Check: cctest.Decode(&batch, func() error {
+				checksum, _ := hashJSON(batch.Records)
+				return expect(len(batch.Records) == 2 && batch.Checksum == checksum && strings.HasPrefix(batch.Bookmark, "0:"),
+					"2 records, checksum and bookmark", batch)
+			})},
+		{Name: "indexes", Identity: f.admin, Function: "exportState", Args: []string{"200", "3:"},
+			Check: cctest.Decode(&batch, func() error {
+				return expect(len(batch.Records) > 0 && strings.HasPrefix(batch.Records[0].Key, "\x00"+STATS_DELTA_INDEX) && batch.Bookmark == "4:",
+					"stats deltas", batch)
+			})},
+		{Name: "badge versions", Identity: f.admin, Function: "exportState", Args: []string{"200", "4:"},
+			Check: cctest.Decode(&batch, func() error {
+				return expect(len(batch.Records) == 2 && strings.HasPrefix(batch.Records[0].Key, "\x00"+BADGE_VERSION_INDEX) && batch.Bookmark == "",
+					"2 badge versions, last page", batch)
+			})},
+	})
+}
+
+func TestImportState(t *testing.T) {
+	f := newFixture(t)
+
+	importArgs := func(checksum string, records ...StateRecord) []string {
+		if checksum == "" {
+			checksum, _ = hashJSON(records)
+		}
+		out, _ := json.Marshal(StateBatch{Records: records, Checksum: checksum})
+		return []string{string(out)}
+	}
+	org1Policy := orgPolicy(t, "Org1MSP")
+	newIssuer := func(name string) StateRecord {
+		return StateRecord{Key: "new@example.com", Policy: org1Policy,
+			Value: []byte(`{"id":"new@example.com","url":"","name":"` + name + `","email":"new@example.com","type":"","revocationList":""}`)}
+	}
+	withPolicy := func(record StateRecord, policy []byte) StateRecord {
+		record.Policy = policy
+		return record
+	}
+
+	// a badge of issuer2, registered in Org2
+	var foreignBadge Badge
+	fatalOnError(t, json.Unmarshal(f.stub.State[f.badge2], &foreignBadge))
+	foreignBadge.Id = makeBadgeID(testIssuer2, "copy")
+	foreignBadgeBytes, err := json.Marshal(foreignBadge)
+	fatalOnError(t, err)
+
+	var mutation MutationResult
+	f.stub.Run(t, []cctest.Case{
+		{Name: "not admin", Identity: f.issuer1, Function: "importState",
+			Args: importArgs("", StateRecord{Key: ISSUER_LIST, Value: []byte(`{"issuers":[]}`)}), Code: ccerrors.Forbidden},
+		{Name: "empty batch", Identity: f.admin, Function: "importState", Args: importArgs(""), Code: ccerrors.InvalidArgument},
+		{Name: "checksum mismatch", Identity: f.admin, Function: "importState",
+			Args: importArgs("sha256$00", StateRecord{Key: ISSUER_LIST, Value: []byte(`{"issuers":[]}`)}), Code: ccerrors.InvalidArgument},
+		{Name: "invalid record", Identity: f.admin, Function: "importState",
+			Args: importArgs("", StateRecord{Key: f.badge1 + "-copy", Value: []byte(`{"id":"x"}`)}), Code: ccerrors.DataCorrupted},
+		{Name: "no policy", Identity: f.admin, Function: "importState",
+			Args: importArgs("", withPolicy(newIssuer("New"), nil)), Code: ccerrors.Forbidden},
+		{Name: "policy of another org", Identity: f.admin, Function: "importState",
+			Args: importArgs("", withPolicy(newIssuer("New"), orgPolicy(t, "Org2MSP"))), Code: ccerrors.Forbidden},
+		{Name: "index with a policy", Identity: f.admin, Function: "importState",
+			Args: importArgs("", StateRecord{Key: ISSUER_LIST, Value: []byte(`{"issuers":[]}`), Policy: org1Policy}), Code: ccerrors.InvalidArgument},
+		{Name: "badge under another key", Identity: f.admin, Function: "importState",
+			Args: importArgs("", StateRecord{Key: makeBadgeID(testIssuer1, "copy"), Value: foreignBadgeBytes, Policy: org1Policy}), Code: ccerrors.InvalidArgument},
+		{Name: "foreign issuer", Identity: f.admin, Function: "importState",
+			Args: importArgs("", StateRecord{Key: foreignBadge.Id, Value: foreignBadgeBytes, Policy: org1Policy}), Code: ccerrors.Forbidden},
+		{Name: "repeated key", Identity: f.admin, Function: "importState",
+			Args: importArgs("", newIssuer(""), newIssuer("New")), Code: ccerrors.Conflict},
+		{Name: "conflict", Identity: f.admin, Function: "importState",
+			Args: importArgs("", StateRecord{Key: ISSUER_LIST, Value: []byte(`{"issuers":[]}`)}), Code: ccerrors.Conflict},
+		{Name: "imported", Identity: f.admin, Function: "importState", Args: importArgs("", newIssuer("New")),
+			Check: cctest.Decode(&mutation, func() error {
+				var imported events.StateImportedPayload
+				if err := expectEvent(f.stub, events.StateImported, &imported); err != nil {
+					return err
+				}
+				orgs, err := getPolicyOrgs(f.stub.EndorsementPolicies[""]["new@example.com"])
+				if err != nil {
+					return err
+				}
+				return expect(len(mutation.Created) == 1 && mutation.Created[0] == "new@example.com" &&
+					len(imported.Keys) == 1 && imported.Keys[0] == "new@example.com" && len(orgs) == 1 && orgs[0] == "Org1MSP",
+					"issuer created, endorsed by Org1MSP", imported)
+			})},
+		{Name: "same value", Identity: f.admin, Function: "importState", Args: importArgs("", newIssuer("New")),
+			Check: cctest.Decode(&mutation, func() error {
+				return expect(len(mutation.Created) == 0, "nothing created", mutation.Created)
+			})},
+	})
+}
+
+// orgPolicy returns the key-level endorsement policy of a peer of mspID
+func orgPolicy(t *testing.T, mspID string) []byte {
+	endorsementPolicy, err := statebased.NewStateEP(nil)
+	fatalOnError(t, err)
+	fatalOnError(t, endorsementPolicy.AddOrgs(statebased.RoleTypePeer, mspID))
+	policy, err := endorsementPolicy.Policy()
+	fatalOnError(t, err)
+	return policy
+}
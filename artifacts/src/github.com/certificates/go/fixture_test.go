@@ -0,0 +1,133 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/certificates/go/cctest"
+	"github.com/certificates/go/events"
+)
+
+// Test fixture shared by the tests of the chaincode functions: the
+// identities of two issuing organizations, and a ledger on a mock stub (see
+// package cctest) with a badge of each issuer and a certificate of the
+// first one.
+
+const (
+	testIssuer1   = "issuer1@org1.example.com"
+	testIssuer2   = "issuer2@org2.example.com"
+	testRecipient = "student@example.com"
+	testSalt      = "test-salt-0123456789"
+	testPepper    = "test-pepper-0123456789"
+	testDigest    = "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+)
+
+type fixture struct {
+	stub *cctest.Stub
+
+	issuer1, issuer2 *cctest.Identity
+	student, noEmail *cctest.Identity
+	// admin is issuer1 with the admin attribute
+	admin *cctest.Identity
+
+	badge1, badge2 string
+	certID         string
+	// recipient is the transient map of the certificate recipient
+	recipient map[string][]byte
+}
+
+// newLedger returns a fixture with an initialized ledger, without badges
+func newLedger(t testing.TB) *fixture {
+	t.Helper()
+
+	org1, err := cctest.NewCA("Org1MSP")
+	fatalOnError(t, err)
+	org2, err := cctest.NewCA("Org2MSP")
+	fatalOnError(t, err)
+
+	f := &fixture{
+		stub:   cctest.NewStub("certificates", new(SimpleChaincode)),
+		badge1: makeBadgeID(testIssuer1, "data-science"),
+		badge2: makeBadgeID(testIssuer2, "data-science"),
+		certID: CERT_PREFIX + strings.TrimPrefix(makeBadgeID(testIssuer1, "data-science"), BADGE_PREFIX) + "-" +
+			strings.TrimPrefix(hashRecipientIdentity(testRecipient, testSalt), "sha256$"),
+		recipient: cctest.Transient(RECIPIENT_TRANSIENT_KEY, RecipientPrivateDetails{
+			Email: testRecipient, Name: "Student", PublicKey: "ecdsa-koblitz-pubkey:test", Salt: testSalt,
+		}),
+	}
+
+	f.issuer1, err = org1.NewUniversity(testIssuer1)
+	fatalOnError(t, err)
+	f.issuer2, err = org2.NewUniversity(testIssuer2)
+	fatalOnError(t, err)
+	f.student, err = org1.NewIdentity("student", map[string]string{"role": "student", "email": testRecipient})
+	fatalOnError(t, err)
+	f.noEmail, err = org1.NewIdentity("no-email", map[string]string{"role": "university"})
+	fatalOnError(t, err)
+	f.admin, err = org1.NewIdentity(testIssuer1, map[string]string{"role": "university", "email": testIssuer1, "admin": "true"})
+	fatalOnError(t, err)
+
+	f.invoke(t, f.issuer1, nil, "initLedger")
+	f.invoke(t, f.admin, map[string][]byte{RECIPIENT_PEPPER_TRANSIENT_KEY: []byte(testPepper)}, "setRecipientPepper")
+	return f
+}
+
+// newFixture returns a fixture with the badges and the certificate issued
+func newFixture(t testing.TB) *fixture {
+	t.Helper()
+
+	f := newLedger(t)
+	f.invoke(t, f.issuer1, nil, "issueBadge", badgeArgs("https://org1.example.com", "Data Science", "")...)
+	f.invoke(t, f.issuer2, nil, "issueBadge", badgeArgs("https://org2.example.com", "Data Science", "data-science")...)
+	f.invoke(t, f.issuer1, f.recipient, "issueCertificate", certArgs("data-science")...)
+	return f
+}
+
+// invoke runs a transaction that must succeed and returns its payload
+func (f *fixture) invoke(t testing.TB, identity *cctest.Identity, transient map[string][]byte, function string, args ...string) []byte {
+	t.Helper()
+
+	resp := f.stub.As(identity).InvokeWithTransient(transient, function, args...)
+	if resp.Status >= 400 {
+		t.Fatalf("%s: %s", function, resp.Message)
+	}
+	return resp.Payload
+}
+
+func badgeArgs(url, name, slug string) []string {
+	return []string{"University", url, name, "Badge description", "Badge criteria", "Dean", "Signature",
+		"data,python", "science", "advanced", "en", slug}
+}
+
+func certArgs(badge string) []string {
+	return []string{"2018-10-17T07:29:47Z", "https://org1.example.com/verify", badge, testDigest}
+}
+
+func expect(ok bool, what string, got interface{}) error {
+	if ok {
+		return nil
+	}
+	return fmt.Errorf("expected %s, got %+v", what, got)
+}
+
+// expectEvent decodes the payload of the event set by the last
+// transaction, which must be of eventType
+func expectEvent(s *cctest.Stub, eventType events.Type, payload interface{}) error {
+	if s.Event == nil || s.Event.EventName != string(eventType) {
+		return fmt.Errorf("expected a %s event, got %v", eventType, s.Event)
+	}
+	envelope, err := events.Decode(s.Event.Payload)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(envelope.Payload, payload)
+}
+
+func fatalOnError(t testing.TB, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
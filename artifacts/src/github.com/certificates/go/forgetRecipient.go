@@ -0,0 +1,170 @@
+package main
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"github.com/certificates/go/ccerrors"
+	"github.com/certificates/go/events"
+	"github.com/certificates/go/validation"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	pb "github.com/hyperledger/fabric/protos/peer"
+)
+
+// Right to erasure: deletes the recipient private data stored in the
+// caller's org collection and marks the affected certificates as
+// subjectErased. Admins only.
+//
+// The recipient email is read from the transient map ("recipient" key, see
+// getRecipientFromTransient), so it is not written to the transaction.
+// Public certificates keep the salted hash, so a verifier holding the
+// original document can still check it (see verifyRecipientIdentity).
+//
+// Peers keep the previous values of deleted private data until the
+// collection blockToLive: they are purged blockToLive blocks after their
+// last write. Live private data is rewritten by renewRecipientData, the
+// erased data is not, so it is purged at the latest blockToLive blocks after
+// the last renewal.
+func (t *SimpleChaincode) forgetRecipient(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+
+	logger.Infof("Action: forget Recipient")
+
+	if len(args) != 0 {
+		return errorResponse(ccerrors.New(ccerrors.InvalidArgument, "Incorrect number of arguments. Expecting 0, recipient must be provided in the transient map"))
+	}
+
+	err := checkUserAttr(stub, "admin", "true")
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	recipientDetails, err := getRecipientFromTransient(stub)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	collection, err := getRecipientCollection(stub)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	// 1. Find recipient certificates using the recipient index (point
+	//    read, Fabric rejects writes after a private data range query)
+	// ----------------------------------------------------------------
+	recipientIndex, recipientIndexKey, err := getRecipientIndex(stub, collection, recipientDetails.Email)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	// sorted, so every endorsing peer returns the same response
+	certIDs := make([]string, 0, len(recipientIndex.CertIDs))
+	for _, certID := range recipientIndex.CertIDs {
+		certIDs = append(certIDs, certID)
+	}
+	sort.Strings(certIDs)
+
+	var erasedCertIDs []string
+	for _, certID := range certIDs {
+		details, err := getRecipientPrivateDetails(stub, collection, certID)
+		if err != nil {
+			return errorResponse(err)
+		}
+		if details == nil || strings.Compare(details.Email, recipientDetails.Email) != 0 {
+			logger.Warningf("Recipient details of %s don't match the index, skipping...", certID)
+			continue
+		}
+
+		// 2. Delete private data
+		// ----------------------
+		err = stub.DelPrivateData(collection, certID)
+		if err != nil {
+			return errorResponse(err)
+		}
+
+		// 3. Mark the public certificate as subjectErased
+		// -----------------------------------------------
+		var cert Certificate
+		err = getCertificateState(stub, certID, &cert)
+		if isNotFound(err) {
+			logger.Warningf("Certificate %s doesn't exist, skipping...", certID)
+			continue
+		}
+		if err != nil {
+			return errorResponse(err)
+		}
+
+		cert.SubjectErased = true
+
+		err = putCertificateState(stub, cert)
+		if err != nil {
+			return errorResponse(err)
+		}
+
+		err = recordStatDelta(stub, cert, STAT_ERASED, 1)
+		if err != nil {
+			return errorResponse(err)
+		}
+		erasedCertIDs = append(erasedCertIDs, cert.Id)
+	}
+
+	if len(erasedCertIDs) == 0 {
+		return errorResponse(ccerrors.New(ccerrors.NotFound, "No certificates found for recipient in "+collection))
+	}
+
+	err = stub.DelPrivateData(collection, recipientIndexKey)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	err = emitEvent(stub, events.RecipientErased, events.RecipientErasedPayload{CertIDs: erasedCertIDs})
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	logger.Infof("Successfully erased recipient data from %d certificates", len(erasedCertIDs))
+	return mutationResponse(stub, nil, erasedCertIDs, nil)
+}
+
+// Query that checks a recipient identity (email) against the salted hash
+// kept in the public certificate. It works for erased subjects too.
+func (t *SimpleChaincode) verifyRecipientIdentity(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+
+	if len(args) != 2 {
+		return errorResponse(ccerrors.New(ccerrors.InvalidArgument, "Incorrect number of arguments. Expecting 2: 1) Certificate ID, 2) Recipient email"))
+	}
+
+	certID, identity := args[0], args[1]
+
+	v := validation.New()
+	v.Key("certificateId", certID)
+	v.Email("recipientEmail", identity)
+	if err := v.Err(); err != nil {
+		return errorResponse(err)
+	}
+
+	var cert Certificate
+	err := getCertificateState(stub, certID, &cert)
+	if isNotFound(err) {
+		return errorResponse(ccerrors.New(ccerrors.NotFound, "Nil value for "+certID))
+	}
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	match := cert.Recipient.Identity == identity
+	if cert.Recipient.Hashed {
+		match = hashRecipientIdentity(identity, cert.Recipient.Salt) == cert.Recipient.Identity
+	}
+
+	out, err := json.Marshal(map[string]interface{}{
+		"id":            cert.Id,
+		"match":         match,
+		"subjectErased": cert.SubjectErased,
+	})
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	return shim.Success(out)
+}
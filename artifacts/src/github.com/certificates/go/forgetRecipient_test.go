@@ -0,0 +1,54 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/certificates/go/ccerrors"
+	"github.com/certificates/go/cctest"
+)
+
+func TestForgetRecipient(t *testing.T) {
+	f := newFixture(t)
+
+	var mutation MutationResult
+	var cert Certificate
+	var identity map[string]interface{}
+	f.stub.Run(t, []cctest.Case{
+		{Name: "arguments", Identity: f.admin, Transient: f.recipient, Function: "forgetRecipient", Args: []string{testRecipient}, Code: ccerrors.InvalidArgument},
+		{Name: "not admin", Identity: f.issuer1, Transient: f.recipient, Function: "forgetRecipient", Code: ccerrors.Forbidden},
+		{Name: "student", Identity: f.student, Transient: f.recipient, Function: "forgetRecipient", Code: ccerrors.Forbidden},
+		{Name: "no recipient", Identity: f.admin, Function: "forgetRecipient", Code: ccerrors.InvalidArgument},
+		{Name: "erased", Identity: f.admin, Transient: f.recipient, Function: "forgetRecipient",
+			Check: cctest.Decode(&mutation, func() error {
+				return expect(len(mutation.Updated) == 1 && mutation.Updated[0] == f.certID, "certificate updated", mutation.Updated)
+			})},
+		{Name: "erased certificate", Identity: f.issuer1, Function: "getCertificate", Args: []string{f.certID},
+			Check: cctest.Decode(&cert, func() error {
+				return expect(cert.SubjectErased && cert.RecipientProfile == nil, "subject erased", cert)
+			})},
+		{Name: "identity still verifiable", Identity: f.issuer2, Function: "verifyRecipientIdentity", Args: []string{f.certID, testRecipient},
+			Check: cctest.Decode(&identity, func() error {
+				return expect(identity["match"] == true && identity["subjectErased"] == true, "match on erased subject", identity)
+			})},
+		{Name: "already erased", Identity: f.admin, Transient: f.recipient, Function: "forgetRecipient", Code: ccerrors.NotFound},
+	})
+}
+
+func TestVerifyRecipientIdentity(t *testing.T) {
+	f := newFixture(t)
+
+	var identity map[string]interface{}
+	f.stub.Run(t, []cctest.Case{
+		{Name: "arguments", Identity: f.issuer2, Function: "verifyRecipientIdentity", Args: []string{f.certID}, Code: ccerrors.InvalidArgument},
+		{Name: "invalid email", Identity: f.issuer2, Function: "verifyRecipientIdentity", Args: []string{f.certID, "student"}, Code: ccerrors.InvalidArgument},
+		{Name: "not found", Identity: f.issuer2, Function: "verifyRecipientIdentity", Args: []string{"cert:nope", testRecipient}, Code: ccerrors.NotFound},
+		{Name: "match", Identity: f.issuer2, Function: "verifyRecipientIdentity", Args: []string{f.certID, testRecipient},
+			Check: cctest.Decode(&identity, func() error {
+				return expect(identity["match"] == true, "match", identity)
+			})},
+		{Name: "no match", Identity: f.issuer2, Function: "verifyRecipientIdentity", Args: []string{f.certID, "other@example.com"},
+			Check: cctest.Decode(&identity, func() error {
+				return expect(identity["match"] == false, "no match", identity)
+			})},
+	})
+}
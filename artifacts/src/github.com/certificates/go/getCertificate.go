@@ -1,6 +1,10 @@
 package main
 
 import (
+	"strings"
+
+	"github.com/certificates/go/canonicaljson"
+	"github.com/certificates/go/ccerrors"
 	"github.com/hyperledger/fabric/core/chaincode/shim"
 	pb "github.com/hyperledger/fabric/protos/peer"
 )
@@ -13,7 +17,7 @@ func (t *SimpleChaincode) getCertificate(stub shim.ChaincodeStubInterface, args
 
 	if len(args) != 1 {
 		logger.Infof("key = %s, len = %d\n", args, len(args))
-		return shim.Error("Incorrect number of arguments. Expecting key to query")
+		return errorResponse(ccerrors.New(ccerrors.InvalidArgument, "Incorrect number of arguments. Expecting key to query"))
 	}
 
 	key = args[0]
@@ -21,16 +25,40 @@ func (t *SimpleChaincode) getCertificate(stub shim.ChaincodeStubInterface, args
 	// Get the state from the ledger
 	KeyValBytes, err := stub.GetState(key)
 	if err != nil {
-		jsonResp := "{\"Error\":\"Failed to get state for " + key + "\"}"
-		return shim.Error(jsonResp)
+		return errorResponse(ccerrors.New(ccerrors.Internal, "Failed to get state for "+key))
 	}
 
 	if KeyValBytes == nil {
-		jsonResp := "{\"Error\":\"Nil value for " + key + "\"}"
-		return shim.Error(jsonResp)
+		return errorResponse(ccerrors.New(ccerrors.NotFound, "Nil value for "+key))
+	}
+
+	// Certificates are joined with the recipient private details when the
+	// caller's org owns them
+	if strings.HasPrefix(key, CERT_PREFIX) {
+		var cert Certificate
+		err = decodeCertificate(stub, key, KeyValBytes, &cert)
+		if err != nil {
+			return errorResponse(err)
+		}
+
+		err = joinRecipientPrivateDetails(stub, &cert)
+		if err != nil {
+			return errorResponse(err)
+		}
+
+		KeyValBytes, err = canonicaljson.Marshal(cert)
+		if err != nil {
+			return errorResponse(err)
+		}
+	} else {
+		// documents stored before canonical JSON are exported canonical
+		KeyValBytes, err = canonicaljson.Transform(KeyValBytes)
+		if err != nil {
+			return errorResponse(corruptState(key, err))
+		}
 	}
 
-	jsonResp := "{\"Name\":\"" + key + "\",\"Value\":\"" + string(KeyValBytes) + "\"}"
-	logger.Infof("Query Response:%s\n", jsonResp)
+	// the value isn't logged, it may hold recipient private details
+	logger.Infof("Query Response for %s", key)
 	return shim.Success(KeyValBytes)
 }
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/certificates/go/canonicaljson"
+	"github.com/certificates/go/ccerrors"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	pb "github.com/hyperledger/fabric/protos/peer"
+)
+
+const CANONICALIZATION = "RFC8785"
+
+// The hashed content of a certificate, so that off-chain tools can check the
+// digest with any RFC 8785 implementation
+type CertificateDigest struct {
+	Id string `json:"id"`
+	// sha256$<hex> of Document, the certificate hash
+	Digest           string `json:"digest"`
+	Canonicalization string `json:"canonicalization"`
+	// issued content: the certificate without hash, status fields and
+	// recipient profile
+	Document json.RawMessage `json:"document"`
+}
+
+// Query that returns the canonical issued content of a certificate and its
+// digest.
+// Argument: 1) Certificate ID
+func (t *SimpleChaincode) getCertificateDigest(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+
+	if len(args) != 1 {
+		return errorResponse(ccerrors.New(ccerrors.InvalidArgument, "Incorrect number of arguments. Expecting 1: Certificate ID"))
+	}
+
+	certID := args[0]
+	if !strings.HasPrefix(certID, CERT_PREFIX) {
+		return errorResponse(ccerrors.New(ccerrors.NotFound, "Certificate "+certID+" doesn't exist"))
+	}
+
+	var cert Certificate
+	err := getCertificateState(stub, certID, &cert)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	document, err := canonicaljson.Marshal(certificateHashContent(cert))
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	out, err := canonicaljson.Marshal(CertificateDigest{
+		Id:               cert.Id,
+		Digest:           hashBytes(document),
+		Canonicalization: CANONICALIZATION,
+		Document:         document,
+	})
+	if err != nil {
+		return errorResponse(err)
+	}
+	return shim.Success(out)
+}
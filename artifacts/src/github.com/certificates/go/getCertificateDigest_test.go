@@ -0,0 +1,29 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/certificates/go/canonicaljson"
+	"github.com/certificates/go/ccerrors"
+	"github.com/certificates/go/cctest"
+)
+
+func TestGetCertificateDigest(t *testing.T) {
+	f := newFixture(t)
+
+	var digest CertificateDigest
+	var cert Certificate
+	f.stub.Run(t, []cctest.Case{
+		{Name: "arguments", Identity: f.issuer2, Function: "getCertificateDigest", Code: ccerrors.InvalidArgument},
+		{Name: "not a certificate", Identity: f.issuer2, Function: "getCertificateDigest", Args: []string{f.badge1}, Code: ccerrors.NotFound},
+		{Name: "digest", Identity: f.issuer2, Function: "getCertificateDigest", Args: []string{f.certID},
+			Setup: func(s *cctest.Stub) {
+				decodeCertificate(s, f.certID, s.State[f.certID], &cert)
+			},
+			Check: cctest.Decode(&digest, func() error {
+				canonical, _ := canonicaljson.Transform(digest.Document)
+				return expect(digest.Digest == cert.Hash && digest.Digest == hashBytes(digest.Document) &&
+					string(canonical) == string(digest.Document), "digest "+cert.Hash+" of a canonical document", digest)
+			})},
+	})
+}
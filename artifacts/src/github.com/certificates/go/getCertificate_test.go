@@ -0,0 +1,41 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/certificates/go/ccerrors"
+	"github.com/certificates/go/cctest"
+)
+
+func TestGetCertificate(t *testing.T) {
+	f := newFixture(t)
+
+	var cert Certificate
+	var stored []byte
+	f.stub.Run(t, []cctest.Case{
+		{Name: "arguments", Identity: f.issuer1, Function: "getCertificate", Code: ccerrors.InvalidArgument},
+		{Name: "not found", Identity: f.issuer1, Function: "getCertificate", Args: []string{"cert:nope"}, Code: ccerrors.NotFound},
+		{Name: "issuer org", Identity: f.issuer1, Function: "getCertificate", Args: []string{f.certID},
+			Check: cctest.Decode(&cert, func() error {
+				return expect(cert.Recipient.Identity == testRecipient && cert.RecipientProfile != nil, "recipient joined", cert.Recipient)
+			})},
+		{Name: "other org", Identity: f.issuer2, Function: "getCertificate", Args: []string{f.certID},
+			Check: cctest.Decode(&cert, func() error {
+				return expect(cert.Recipient.Hashed && cert.RecipientProfile == nil, "recipient hashed", cert.Recipient)
+			})},
+		{Name: "badge reference", Identity: f.issuer2, Function: "getCertificate", Args: []string{f.certID},
+			Setup: func(s *cctest.Stub) {
+				stored = s.State[f.certID]
+			},
+			Check: cctest.Decode(&cert, func() error {
+				return expect(isBadgeReference(stored) && !strings.Contains(string(stored), "signatureLines") &&
+					cert.Badge.Id == f.badge1 && len(cert.Badge.SignatureLines) == 1, "badge reference stored, badge embedded", cert.Badge)
+			})},
+		{Name: "invalid JSON", Identity: f.issuer1, Function: "getCertificate", Args: []string{f.certID},
+			Setup: func(s *cctest.Stub) {
+				s.State[f.certID] = []byte(`{"id":`)
+			},
+			Code: ccerrors.DataCorrupted},
+	})
+}
@@ -0,0 +1,31 @@
+// Package kvflag contains the repeatable name=value flag shared by the
+// simulator and the command line tools.
+package kvflag
+
+import (
+	"errors"
+	"sort"
+	"strings"
+)
+
+// KeyValues is a flag.Value collecting repeated name=value flags (e.g.
+// certificate attributes)
+type KeyValues map[string]string
+
+func (kv KeyValues) String() string {
+	var pairs []string
+	for name, value := range kv {
+		pairs = append(pairs, name+"="+value)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+func (kv KeyValues) Set(pair string) error {
+	parts := strings.SplitN(pair, "=", 2)
+	if len(parts) != 2 || parts[0] == "" {
+		return errors.New("expecting name=value")
+	}
+	kv[parts[0]] = parts[1]
+	return nil
+}
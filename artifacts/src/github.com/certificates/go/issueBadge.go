@@ -1,9 +1,9 @@
 package main
 
 import (
-	"reflect"
-	"strings"
-
+	"github.com/certificates/go/ccerrors"
+	"github.com/certificates/go/events"
+	"github.com/certificates/go/validation"
 	"github.com/hyperledger/fabric/core/chaincode/shim"
 	pb "github.com/hyperledger/fabric/protos/peer"
 )
@@ -12,10 +12,14 @@ func (t *SimpleChaincode) issueBadge(stub shim.ChaincodeStubInterface, args []st
 
 	// check function args. In description, email is omitted in description
 	// because it is obtained from the certificate.
-	if len(args) != 8 {
-		return shim.Error(`Incorrect number of arguments. Expecting 7:\n
-		1) Issuer Name, 2) Issuer URL, 3) Badge Name (id), 4) Badge Description\n
-		5) Badge Criteria, 6) Job Description for Signature, 7) Name for Signature`)
+	// Arguments 8 to 12 are optional (trailing ones can be omitted, empty
+	// strings are ignored).
+	if len(args) < 8 || len(args) > 13 {
+		return errorResponse(ccerrors.New(ccerrors.InvalidArgument, `Incorrect number of arguments. Expecting 7 to 12:\n
+		1) Issuer Name, 2) Issuer URL, 3) Badge Name, 4) Badge Description\n
+		5) Badge Criteria, 6) Job Description for Signature, 7) Name for Signature\n
+		8) Tags (comma separated), 9) Category, 10) Level, 11) Language\n
+		12) Badge slug (a-z, 0-9 and '-', derived from the name if empty)`))
 	}
 
 	logger.Infof("Action: issue Badge")
@@ -23,121 +27,160 @@ func (t *SimpleChaincode) issueBadge(stub shim.ChaincodeStubInterface, args []st
 	badgeName, badgeDesc, criteria := args[3], args[4], args[5]
 	badgeJobDesc, badgeSigName := args[6], args[7]
 
-	var issuer Issuer
-	var issuerList IssuerList
-	issuerExists := false
-	issuerIndexInIssuerList := 0
-
-	var badge Badge
-	var badgeFromLedger Badge
+	optionalArgs := make([]string, 13)
+	copy(optionalArgs, args)
+	badgeTags := splitTags(optionalArgs[8])
+	badgeCategory, badgeLevel, badgeLanguage := optionalArgs[9], optionalArgs[10], optionalArgs[11]
+	badgeSlug := optionalArgs[12]
+
+	v := validation.New()
+	v.Email("issuerEmail", issuerEmail)
+	v.Name("issuerName", issuerName)
+	v.URL("issuerUrl", issuerUrl)
+	v.Name("badgeName", badgeName)
+	v.Text("badgeDescription", badgeDesc)
+	v.Text("criteria", criteria)
+	v.Name("signatureJobTitle", badgeJobDesc)
+	v.Name("signatureName", badgeSigName)
+	for _, tag := range badgeTags {
+		v.OptionalName("tags", tag)
+	}
+	v.OptionalName("category", badgeCategory)
+	v.OptionalName("level", badgeLevel)
+	v.OptionalName("language", badgeLanguage)
+	if err := v.Err(); err != nil {
+		return errorResponse(err)
+	}
 
-	// 1. Get issuerList from ledger
-	// -----------------------------
-	issuerListMap, err := getStructFromLedger(stub, ISSUER_LIST)
+	// Badge ID is namespaced by issuer (see utils_badgeid.go)
+	var err error
+	if badgeSlug == "" {
+		badgeSlug, err = slugify(badgeName)
+	} else {
+		err = validateSlug(badgeSlug)
+	}
 	if err != nil {
-		// error retrieving issuer-list
-		return shim.Error(err.Error())
+		return errorResponse(err)
 	}
-	// Convert map[string] to IssuerList struct
-	FillStruct(issuerListMap, &issuerList)
-
-	// 2. Check if issuer exist in ledger
-	// ----------------------------------
-	for i, issuerSum := range issuerList.IssuerSummary {
-		if strings.Compare(issuerSum.Email, issuerEmail) == 0 {
-			logger.Infof("Issuer already exists, skipping issuer creation...")
-			issuerExists = true
-			issuerIndexInIssuerList = i
-		}
+	badgeID := makeBadgeID(issuerEmail, badgeSlug)
+
+	var issuer Issuer
+	var badge Badge
+
+	// 1. Get issuer from ledger (KEY: issuer email)
+	// ---------------------------------------------
+	err = getState(stub, issuerEmail, &issuer)
+	issuerExists := err == nil
+	if err != nil && !isNotFound(err) {
+		// error retrieving issuer
+		return errorResponse(err)
 	}
 
-	// 3. If issuer doesn't exist in ledger, create it and append
-	//    its summary to issuerList
-	// ----------------------------------------------------------
+	// 2. If issuer doesn't exist in ledger, create it and append
+	//    its summary to issuerList. The issuer-list is only written
+	//    when an issuer is registered, so issuance doesn't depend on
+	//    the number of badges and certificates in the ledger
+	// ------------------------------------------------------------
+	var updated []string
 	if !issuerExists {
 		logger.Infof("Issuer doesn't exist, creating...")
 		// Create Issuer struct
 		issuer = createIssuer(issuerEmail, issuerUrl, issuerEmail, issuerName)
 
-		// Create IssuerSummary struct
-		issuerSummary := createIssuerSummary(issuer)
-
 		err = marshalAndPutState(stub, issuer, issuerEmail)
 		if err != nil {
 			// error marshaling or putting state into ledger
-			return shim.Error(err.Error())
+			return errorResponse(err)
 		}
 
-		// Append issuerSummary to issuerList
-		issuerList.IssuerSummary = append(issuerList.IssuerSummary, issuerSummary)
-		issuerIndexInIssuerList = len(issuerList.IssuerSummary) - 1
-	} else {
-		// 4. Issuer exists, get issuer from ledger
-		// ----------------------------------------
-		issuerMap, err := getStructFromLedger(stub, issuerEmail)
+		// Only the issuer's org can endorse further updates
+		err = setIssuerEndorsementPolicy(stub, issuerEmail)
 		if err != nil {
-			// error retrieving issuer
-			return shim.Error(err.Error())
+			return errorResponse(err)
 		}
-		// Convert map[string] to struct
-		FillStruct(issuerMap, &issuer)
 
-		// check if user is not empty
-		if reflect.DeepEqual(issuer, Issuer{}) {
-			// issuer doesn't exist in ledger, aborting
-			return shim.Error("Issuer is empty in ledger, aborting!")
+		// Append issuerSummary to issuerList
+		var issuerList IssuerList
+		err = getState(stub, ISSUER_LIST, &issuerList)
+		if err != nil && !isNotFound(err) {
+			// error retrieving issuer-list (missing issuer-list is empty)
+			return errorResponse(err)
 		}
+		issuerList.IssuerSummary = append(issuerList.IssuerSummary, createIssuerSummary(issuer))
 
+		// Write the state into the ledger (KEY: issuer-list, unique)
+		err = marshalAndPutState(stub, issuerList, ISSUER_LIST)
+		if err != nil {
+			// error marshaling or putting state into ledger
+			return errorResponse(err)
+		}
+		updated = []string{ISSUER_LIST}
+	} else {
 		logger.Infof("Issuer %s found in ledger!", issuerEmail)
 	}
 
-	// 5. Create a Badge (it includes issuer) and write it to the ledger
+	// 3. Create a Badge (it includes issuer) and write it to the ledger
 	// -----------------------------------------------------------------
-	// Badge ID will be the badge name without spaces and in lowercase
-	badgeID := BADGE_PREFIX + strings.ToLower(strings.Replace(badgeName, " ", "", -1))
-
-	// get Badge from ledger
-	badgeFromLedgerMap, err := getStructFromLedger(stub, badgeID)
+	// Check if badge exists
+	badgeExists, err := stateExists(stub, badgeID)
 	if err != nil {
 		// error retrieving badge
-		return shim.Error(err.Error())
+		return errorResponse(err)
 	}
-	// Convert map[string] to Badge struct
-	FillStruct(badgeFromLedgerMap, &badgeFromLedger)
 
-	// Check if badge exists (not empty)
-	if reflect.DeepEqual(badgeFromLedger, Badge{}) {
+	if !badgeExists {
 		logger.Infof("Badge doesn't exist, creating...")
 		// creating elements from parameters
 		badgeSignatureLines := createSignatureLines(badgeJobDesc, badgeSigName)
 		badgeCriteria := createCriteria(criteria)
 		// Create Badge
 		badge = createBadge(badgeID, badgeName, badgeDesc, issuer, badgeCriteria, badgeSignatureLines)
+		// Catalogue metadata
+		badge.Tags, badge.Category = badgeTags, badgeCategory
+		badge.Level, badge.Language = badgeLevel, badgeLanguage
 	} else {
 		// if badge exist, abort
 		logger.Errorf("Badge exists, aborting...")
-		return shim.Error("Badge already exists, aborting!")
+		return errorResponse(ccerrors.New(ccerrors.AlreadyExists, "Badge "+badgeID+" already exists, choose a different slug"))
 	}
 
 	// Write the badge into the ledger (KEY: id, unique)
 	err = marshalAndPutState(stub, badge, badge.Id)
 	if err != nil {
 		// error marshaling or putting state into ledger
-		return shim.Error(err.Error())
+		return errorResponse(err)
 	}
 
-	// 6. Append badgeID to badgeIDs in IssuerSummary
-	// ----------------------------------------------
-	issuerList.IssuerSummary[issuerIndexInIssuerList].BagdeIDs =
-		append(issuerList.IssuerSummary[issuerIndexInIssuerList].BagdeIDs, badgeID)
+	// Only the issuer's org can endorse further updates of the badge
+	err = setIssuerEndorsementPolicy(stub, badge.Id)
+	if err != nil {
+		return errorResponse(err)
+	}
 
-	// Write the state into the ledger (KEY: issuer-list, unique)
-	err = marshalAndPutState(stub, issuerList, ISSUER_LIST)
+	// First version of the badge, referenced by its certificates
+	_, err = putBadgeVersion(stub, badge)
 	if err != nil {
-		// error marshaling or putting state into ledger
-		return shim.Error(err.Error())
+		return errorResponse(err)
 	}
 
-	returnMessage := "Successfully updated blockchain: CREATED Badge and UPDATED issuerList"
-	return shim.Success([]byte(returnMessage))
+	// Add the badge to the catalogue search index
+	err = indexBadgeTerms(stub, badge)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	err = emitEvent(stub, events.BadgeIssued, events.BadgeIssuedPayload{
+		BadgeID:       badge.Id,
+		IssuerID:      issuer.Id,
+		IssuerCreated: !issuerExists,
+	})
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	created := []string{badge.Id}
+	if !issuerExists {
+		created = append(created, issuer.Id)
+	}
+	return mutationResponse(stub, created, updated, badge)
 }
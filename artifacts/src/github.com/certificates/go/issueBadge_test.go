@@ -0,0 +1,30 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/certificates/go/ccerrors"
+	"github.com/certificates/go/cctest"
+)
+
+func TestIssueBadge(t *testing.T) {
+	f := newLedger(t)
+
+	var mutation MutationResult
+	f.stub.Run(t, []cctest.Case{
+		{Name: "arguments", Identity: f.issuer1, Function: "issueBadge", Args: []string{"Org1"}, Code: ccerrors.InvalidArgument},
+		{Name: "invalid URL", Identity: f.issuer1, Function: "issueBadge",
+			Args: badgeArgs("org1.example.com", "Data Science", ""), Code: ccerrors.InvalidArgument},
+		{Name: "invalid slug", Identity: f.issuer1, Function: "issueBadge",
+			Args: badgeArgs("https://org1.example.com", "Data Science", "Data Science"), Code: ccerrors.InvalidArgument},
+		{Name: "issued", Identity: f.issuer1, Function: "issueBadge",
+			Args: badgeArgs("https://org1.example.com", "Data Science", ""),
+			Check: cctest.Decode(&mutation, func() error {
+				return expect(len(mutation.Created) == 2 && mutation.Created[0] == f.badge1, "badge and issuer created", mutation.Created)
+			})},
+		{Name: "already exists", Identity: f.issuer1, Function: "issueBadge",
+			Args: badgeArgs("https://org1.example.com", "Data  Science!", ""), Code: ccerrors.AlreadyExists},
+		{Name: "same name in another issuer", Identity: f.issuer2, Function: "issueBadge",
+			Args: badgeArgs("https://org2.example.com", "Data Science", "data-science")},
+	})
+}
@@ -1,9 +1,12 @@
 package main
 
 import (
-	"reflect"
 	"strings"
+	"time"
 
+	"github.com/certificates/go/ccerrors"
+	"github.com/certificates/go/events"
+	"github.com/certificates/go/validation"
 	"github.com/hyperledger/fabric/core/chaincode/shim"
 	pb "github.com/hyperledger/fabric/protos/peer"
 )
@@ -11,127 +14,195 @@ import (
 func (t *SimpleChaincode) issueCertificate(stub shim.ChaincodeStubInterface, args []string) pb.Response {
 
 	logger.Infof("Action: issue Certificate")
-	var returnMessage string
 
 	// check function args. In description, email is omitted in description
 	// because it is obtained from the certificate.
-	if len(args) != 7 {
-		return shim.Error(`Incorrect number of arguments. Expecting 6:\n
-		1) issuedOn, 2) Recipient Email, 3) recipient Name, 4) Recipient Public Key, 
-		5) Certificate location, 6) Badge ID (name of the badge without spaces in lowercase)\n`)
+	// Recipient email, name and public key are passed in the transient map
+	// (see getRecipientFromTransient).
+	// Attachment digests (4) and expiry date (5) are optional, they can be
+	// empty.
+	if len(args) < 4 || len(args) > 6 {
+		return errorResponse(ccerrors.New(ccerrors.InvalidArgument, `Incorrect number of arguments. Expecting 3 to 5:\n
+		1) issuedOn, 2) Certificate location,
+		3) Badge slug or Badge ID\n
+		4) Attachment SHA-256 digests (hex, comma separated)\n
+		5) Expiry date (RFC 3339)\n`))
 	}
 
 	// Parameters
 	issuerEmail, issuedOn := args[0], args[1]
-	recipientEmail := args[2]
-	recipientName, recipientPubKey := args[3], args[4]
-	location := args[5]
-	badgeKey := args[6]
+	location := args[2]
+
+	v := validation.New()
+	v.DateTime("issuedOn", issuedOn)
+	v.URL("location", location)
+	v.Key("badge", args[3])
+	var expires string
+	if len(args) == 6 && args[5] != "" {
+		expires = args[5]
+		v.DateTime("expires", expires)
+	}
+	if err := v.Err(); err != nil {
+		return errorResponse(err)
+	}
+	if expires != "" {
+		issuedTime, _ := time.Parse(time.RFC3339, issuedOn)
+		expiresTime, _ := time.Parse(time.RFC3339, expires)
+		if !expiresTime.After(issuedTime) {
+			return errorResponse(ccerrors.New(ccerrors.InvalidArgument, "expires must be after issuedOn"))
+		}
+	}
+
+	var attachments []Attachment
+	if len(args) >= 5 {
+		digests, err := parseDocumentDigests(args[4])
+		if err != nil {
+			return errorResponse(err)
+		}
+		for _, digest := range digests {
+			attachments = append(attachments, createAttachment(digest))
+		}
+	}
+
+	// Recipient private details. The salt is chosen by the client, it must
+	// be random: the transaction ID is public and would let anyone check
+	// a guessed email against the hashed identity
+	recipientDetails, err := getRecipientFromTransient(stub)
+	if err != nil {
+		return errorResponse(err)
+	}
+	v = validation.New()
+	v.Secret("recipient.salt", recipientDetails.Salt)
+	if err := v.Err(); err != nil {
+		return errorResponse(err)
+	}
 
-	var issuerList IssuerList
-	issuerExists := false
-	issuerIndexInIssuerList := 0
+	collection, err := getRecipientCollection(stub)
+	if err != nil {
+		return errorResponse(err)
+	}
 
 	var badgeFromLedger Badge
 
 	var cert Certificate
-	var certFromLedger Certificate
 
 	// 1. Check if badge exists in ledger and if it is owned by the certificate issuer
 	// -------------------------------------------------------------------------------
 	// get Badge from ledger
-	badgeFromLedgerMap, err := getStructFromLedger(stub, BADGE_PREFIX+badgeKey)
+	badgeID, err := getBadgeState(stub, issuerEmail, args[3], &badgeFromLedger)
+	if isNotFound(err) {
+		logger.Errorf("Provided Badge doesn't exist, aborting...")
+		return errorResponse(ccerrors.New(ccerrors.NotFound, "Badge doesn't exist, aborting"))
+	}
 	if err != nil {
 		// error retrieving badge
-		return shim.Error(err.Error())
-	}
-	// Convert map[string] to Badge struct
-	FillStruct(badgeFromLedgerMap, &badgeFromLedger)
-
-	// Check if badge exists (not empty)
-	if reflect.DeepEqual(badgeFromLedger, Badge{}) {
-		logger.Errorf("Provided Badge doesn't exist, aborting...")
-		return shim.Error("Badge doesn't exist, aborting")
+		return errorResponse(err)
 	}
 
 	// Check if badge is owned by issuer
 	if strings.Compare(badgeFromLedger.Issuer.Id, issuerEmail) != 0 {
-		return shim.Error("Badge is not owned by " + issuerEmail)
+		return errorResponse(ccerrors.New(ccerrors.Forbidden, "Badge is not owned by "+issuerEmail))
+	}
+
+	// 2. Check that the recipient doesn't hold this badge yet. The
+	//    recipient index lives in the private collection, so the
+	//    recipient email is never exposed in channel state
+	// ----------------------------------------------------------------
+	badgeKey := strings.TrimPrefix(badgeID, BADGE_PREFIX)
+
+	recipientIndex, recipientIndexKey, err := getRecipientIndex(stub, collection, recipientDetails.Email)
+	if err != nil {
+		return errorResponse(err)
+	}
+	if _, ok := recipientIndex.CertIDs[badgeID]; ok {
+		logger.Errorf("Certificate exists, aborting...")
+		return errorResponse(ccerrors.New(ccerrors.AlreadyExists, "Certificate already exists, aborting!"))
 	}
 
-	// 2. Create certificate if it doesn't exist
+	// 3. Create certificate if it doesn't exist
 	// -----------------------------------------
-	// create Certificate ID (cert:recipientEmail-badgeKey)
-	certID := CERT_PREFIX + recipientEmail + "-" + badgeKey
+	rec := createHashedRecipient(recipientDetails.Email, recipientDetails.Salt)
 
-	// get certificate from ledger
-	certFromLedgerMap, err := getStructFromLedger(stub, certID)
+	// create Certificate ID (cert:issuerID/slug-recipientHash)
+	certID := CERT_PREFIX + badgeKey + "-" + strings.TrimPrefix(rec.Identity, "sha256$")
+
+	// Check if certificate exists
+	certExists, err := stateExists(stub, certID)
 	if err != nil {
 		// error retrieving cert
-		return shim.Error(err.Error())
+		return errorResponse(err)
 	}
-	// Convert map[string] to Certificate struct
-	FillStruct(certFromLedgerMap, &certFromLedger)
 
-	// Check if certificate exists (not empty)
-	if reflect.DeepEqual(certFromLedger, Certificate{}) {
+	if !certExists {
 		logger.Infof("Certificate doesn't exist, creating...")
 
 		// creating elements from parameters
-		rec := createRecipient(recipientEmail)
-		recProf := createRecipientProfile(recipientPubKey, recipientName)
 		ver := createVerification(location)
 
-		// Create Certificate
-		cert = createCertificate(certID, issuedOn, rec, recProf, ver, badgeFromLedger)
+		// Create Certificate (recipient profile is kept private)
+		cert = createCertificate(certID, issuedOn, rec, nil, ver, badgeFromLedger)
+		cert.Attachments = attachments
+		cert.Expires = expires
+
+		// Integrity hash, checked by verifyCertificate
+		cert.Hash, err = computeCertificateHash(cert)
+		if err != nil {
+			return errorResponse(err)
+		}
 	} else {
 		// if certificate exist, abort
 		logger.Errorf("Certificate exists, aborting...")
-		return shim.Error("Certificate already exists, aborting!")
+		return errorResponse(ccerrors.New(ccerrors.AlreadyExists, "Certificate already exists, aborting!"))
 	}
 
-	// Write the cert into the ledger (KEY: certId, unique)
-	err = marshalAndPutState(stub, cert, cert.Id)
+	// Write the cert into the ledger (KEY: certId, unique), with a reference
+	// to the badge version
+	err = putCertificateState(stub, cert)
 	if err != nil {
 		// error marshaling or putting state into ledger
-		return shim.Error(err.Error())
+		return errorResponse(err)
 	}
 
-	// 3. Append certID to certIDs in IssuerSummary and update issuer-list
-	// -------------------------------------------------------------------
-	// Get issuerList from ledger
-	issuerListMap, err := getStructFromLedger(stub, ISSUER_LIST)
+	// Only the issuer's org can endorse further updates (e.g. revocation)
+	err = setIssuerEndorsementPolicy(stub, cert.Id)
 	if err != nil {
-		// error retrieving issuer-list
-		return shim.Error(err.Error())
-	}
-	// Convert map[string] to IssuerList struct
-	FillStruct(issuerListMap, &issuerList)
-
-	// Find issuer position in issuerList
-	for i, issuerSum := range issuerList.IssuerSummary {
-		if strings.Compare(issuerSum.Email, issuerEmail) == 0 {
-			logger.Infof("Found Issuer: " + issuerEmail)
-			issuerExists = true
-			issuerIndexInIssuerList = i
-		}
+		return errorResponse(err)
+	}
+
+	// Index attached documents (document hash -> certificate)
+	err = indexDocumentDigests(stub, cert)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	// Issuer and badge statistics
+	err = recordStatDelta(stub, cert, STAT_ISSUED, 1)
+	if err != nil {
+		return errorResponse(err)
 	}
-	if !issuerExists {
-		logger.Errorf("Issuer doesn't exist")
-		return shim.Error("Issuer doesn't exist")
+
+	// Write recipient details and recipient index into the issuer org
+	// collection
+	err = marshalAndPutPrivateData(stub, collection, recipientDetails, cert.Id)
+	if err != nil {
+		return errorResponse(err)
 	}
 
-	// Append certID to certIDs slice
-	issuerList.IssuerSummary[issuerIndexInIssuerList].CertIDs =
-		append(issuerList.IssuerSummary[issuerIndexInIssuerList].CertIDs, certID)
+	recipientIndex.CertIDs[badgeID] = cert.Id
+	err = marshalAndPutPrivateData(stub, collection, recipientIndex, recipientIndexKey)
+	if err != nil {
+		return errorResponse(err)
+	}
 
-	// Write the state into the ledger (KEY: issuer-list, unique)
-	err = marshalAndPutState(stub, issuerList, ISSUER_LIST)
+	err = emitEvent(stub, events.CertificateIssued, events.CertificateIssuedPayload{
+		CertID:   cert.Id,
+		BadgeID:  badgeFromLedger.Id,
+		IssuerID: issuerEmail,
+		IssuedOn: cert.IssuedOn,
+	})
 	if err != nil {
-		// error marshaling or putting state into ledger
-		return shim.Error(err.Error())
+		return errorResponse(err)
 	}
 
-	returnMessage = "Successfully updated blockchain: CREATED Certificate and UPDATED issuerList"
-	return shim.Success([]byte(returnMessage))
+	return mutationResponse(stub, []string{cert.Id}, nil, cert)
 }
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/certificates/go/ccerrors"
+	"github.com/certificates/go/cctest"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	pb "github.com/hyperledger/fabric/protos/peer"
+)
+
+func TestIssueCertificate(t *testing.T) {
+	f := newLedger(t)
+	f.invoke(t, f.issuer1, nil, "issueBadge", badgeArgs("https://org1.example.com", "Data Science", "")...)
+	f.invoke(t, f.issuer2, nil, "issueBadge", badgeArgs("https://org2.example.com", "Data Science", "data-science")...)
+
+	var mutation MutationResult
+	f.stub.Run(t, []cctest.Case{
+		{Name: "arguments", Identity: f.issuer1, Transient: f.recipient, Function: "issueCertificate",
+			Args: []string{"data-science"}, Code: ccerrors.InvalidArgument},
+		{Name: "no recipient", Identity: f.issuer1, Function: "issueCertificate",
+			Args: certArgs("data-science"), Code: ccerrors.InvalidArgument},
+		{Name: "invalid recipient email", Identity: f.issuer1, Function: "issueCertificate",
+			Transient: cctest.Transient(RECIPIENT_TRANSIENT_KEY, RecipientPrivateDetails{Email: "student"}),
+			Args:      certArgs("data-science"), Code: ccerrors.InvalidArgument},
+		{Name: "no salt", Identity: f.issuer1, Function: "issueCertificate",
+			Transient: cctest.Transient(RECIPIENT_TRANSIENT_KEY, RecipientPrivateDetails{Email: testRecipient}),
+			Args:      certArgs("data-science"), Code: ccerrors.InvalidArgument},
+		{Name: "short salt", Identity: f.issuer1, Function: "issueCertificate",
+			Transient: cctest.Transient(RECIPIENT_TRANSIENT_KEY, RecipientPrivateDetails{Email: testRecipient, Salt: "salt"}),
+			Args:      certArgs("data-science"), Code: ccerrors.InvalidArgument},
+		{Name: "invalid issuedOn", Identity: f.issuer1, Transient: f.recipient, Function: "issueCertificate",
+			Args: []string{"yesterday", "https://org1.example.com/verify", "data-science"}, Code: ccerrors.InvalidArgument},
+		{Name: "invalid digest", Identity: f.issuer1, Transient: f.recipient, Function: "issueCertificate",
+			Args: []string{"2018-10-17T07:29:47Z", "https://org1.example.com/verify", "data-science", "abc"}, Code: ccerrors.InvalidArgument},
+		{Name: "invalid expires", Identity: f.issuer1, Transient: f.recipient, Function: "issueCertificate",
+			Args: append(certArgs("data-science"), "tomorrow"), Code: ccerrors.InvalidArgument},
+		{Name: "expires before issuedOn", Identity: f.issuer1, Transient: f.recipient, Function: "issueCertificate",
+			Args: append(certArgs("data-science"), "2017-10-17T07:29:47Z"), Code: ccerrors.InvalidArgument},
+		{Name: "unknown badge", Identity: f.issuer1, Transient: f.recipient, Function: "issueCertificate",
+			Args: certArgs("nope"), Code: ccerrors.NotFound},
+		{Name: "badge of another issuer", Identity: f.issuer1, Transient: f.recipient, Function: "issueCertificate",
+			Args: certArgs(f.badge2), Code: ccerrors.Forbidden},
+		{Name: "issued", Identity: f.issuer1, Transient: f.recipient, Function: "issueCertificate",
+			Args: certArgs("data-science"),
+			Check: cctest.Decode(&mutation, func() error {
+				return expect(len(mutation.Created) == 1 && mutation.Created[0] == f.certID, "certificate "+f.certID+" created", mutation.Created)
+			})},
+		{Name: "already exists", Identity: f.issuer1, Transient: f.recipient, Function: "issueCertificate",
+			Args: certArgs(f.badge1), Code: ccerrors.AlreadyExists},
+	})
+}
+
+// Badges issued before the IDs were namespaced are keyed badge:<name>
+func TestIssueCertificateLegacyBadge(t *testing.T) {
+	f := newLedger(t)
+	f.invoke(t, f.issuer1, nil, "issueBadge", badgeArgs("https://org1.example.com", "Data Science", "")...)
+
+	legacyID := BADGE_PREFIX + "datascience"
+	var badge Badge
+	fatalOnError(t, json.Unmarshal(f.stub.State[f.badge1], &badge))
+	badge.Id = legacyID
+	badgeBytes, err := json.Marshal(badge)
+	fatalOnError(t, err)
+	f.stub.State[legacyID] = badgeBytes
+	delete(f.stub.State, f.badge1)
+
+	var mutation MutationResult
+	var cert Certificate
+	f.stub.Run(t, []cctest.Case{
+		{Name: "legacy badge of another issuer", Identity: f.issuer2, Transient: f.recipient, Function: "issueCertificate",
+			Args: certArgs("datascience"), Code: ccerrors.Forbidden},
+		{Name: "issued", Identity: f.issuer1, Transient: f.recipient, Function: "issueCertificate",
+			Args: certArgs(legacyID),
+			Check: cctest.Decode(&mutation, func() error {
+				return expect(len(mutation.Created) == 1 && strings.HasPrefix(mutation.Created[0], CERT_PREFIX+"datascience-"),
+					"certificate of "+legacyID+" created", mutation.Created)
+			})},
+		{Name: "legacy badge kept", Identity: f.issuer1, Function: "getCertificate", Args: []string{CERT_PREFIX + "datascience-" +
+			strings.TrimPrefix(hashRecipientIdentity(testRecipient, testSalt), "sha256$")},
+			Check: cctest.Decode(&cert, func() error {
+				return expect(cert.Badge.Id == legacyID, "badge "+legacyID, cert.Badge.Id)
+			})},
+		{Name: "already exists", Identity: f.issuer1, Transient: f.recipient, Function: "issueCertificate",
+			Args: certArgs("datascience"), Code: ccerrors.AlreadyExists},
+	})
+}
+
+// numbers of certificates already in the ledger of the benchmarks
+var benchSizes = []int{1000, 10000, 100000}
+
+// BenchmarkIssueCertificate measures issueCertificate end to end, against
+// ledgers of growing size. Its cost must not grow with the ledger.
+func BenchmarkIssueCertificate(b *testing.B) {
+	for _, n := range benchSizes {
+		b.Run("certs="+strconv.Itoa(n), func(b *testing.B) {
+			f := newBenchLedger(b, n)
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			written := 0
+			for i := 0; i < b.N; i++ {
+				email := "bench" + strconv.Itoa(i) + "@example.com"
+				resp := f.stub.InvokeWithTransient(benchRecipient(email), "issueCertificate", certArgs("data-science")...)
+				if resp.Status >= 400 {
+					b.Fatal(resp.Message)
+				}
+				written += writeSetSize(f.stub.RWSet)
+			}
+			b.ReportMetric(float64(written)/float64(b.N), "write-B/op")
+		})
+	}
+}
+
+// BenchmarkLegacyIssuerList measures the step issueCertificate no longer
+// runs: decoding the issuer-list (with the certIDs of every certificate),
+// appending the new certificate and writing it back. Its cost grows with
+// the ledger.
+func BenchmarkLegacyIssuerList(b *testing.B) {
+	for _, n := range benchSizes {
+		b.Run("certs="+strconv.Itoa(n), func(b *testing.B) {
+			f := newBenchLedger(b, n)
+			legacy := cctest.NewStub("certificates", legacyChaincode{}).As(f.issuer1)
+			legacy.Load(f.stub.State, f.stub.PvtState)
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			written := 0
+			for i := 0; i < b.N; i++ {
+				resp := legacy.Invoke("appendCertID", CERT_PREFIX+"legacy-"+strconv.Itoa(i))
+				if resp.Status >= 400 {
+					b.Fatal(resp.Message)
+				}
+				written += writeSetSize(legacy.RWSet)
+			}
+			b.ReportMetric(float64(written)/float64(b.N), "write-B/op")
+		})
+	}
+}
+
+// newBenchLedger returns a fixture with n certificates of badge1. One
+// certificate is issued, its keys (certificate, indexes, statistics and
+// private data) are then cloned n-1 times under other recipients. The
+// issuer-list has the legacy certIDs of all of them.
+func newBenchLedger(b *testing.B, n int) *fixture {
+	b.Helper()
+
+	f := newLedger(b)
+	f.invoke(b, f.issuer1, nil, "issueBadge", badgeArgs("https://org1.example.com", "Data Science", "")...)
+	f.invoke(b, f.issuer1, f.recipient, "issueCertificate", certArgs("data-science")...)
+	certWrites := f.stub.RWSet.Writes
+
+	var issuerList IssuerList
+	err := decodeState(ISSUER_LIST, f.stub.State[ISSUER_LIST], &issuerList)
+	fatalOnError(b, err)
+	issuerList.IssuerSummary[0].CertIDs = append(issuerList.IssuerSummary[0].CertIDs, f.certID)
+
+	certIDPrefix := strings.TrimSuffix(f.certID, strings.TrimPrefix(hashRecipientIdentity(testRecipient, testSalt), "sha256$"))
+	for i := 1; i < n; i++ {
+		id := fmt.Sprintf("%s%064x", certIDPrefix, i)
+		email := "seed" + strconv.Itoa(i) + "@example.com"
+		clone := strings.NewReplacer(f.certID, id, testRecipient, email)
+		for _, w := range certWrites {
+			value := []byte(clone.Replace(string(w.Value)))
+			switch {
+			case w.Collection == "":
+				f.stub.State[clone.Replace(w.Key)] = value
+			case strings.HasPrefix(w.Key, RECIPIENT_INDEX_PREFIX):
+				f.stub.PvtState[w.Collection][recipientIndexKey(testPepper, email)] = value
+			default:
+				f.stub.PvtState[w.Collection][clone.Replace(w.Key)] = value
+			}
+		}
+		issuerList.IssuerSummary[0].CertIDs = append(issuerList.IssuerSummary[0].CertIDs, id)
+	}
+
+	f.stub.State[ISSUER_LIST], err = json.Marshal(issuerList)
+	fatalOnError(b, err)
+	// sorts the keys again
+	f.stub.Load(f.stub.State, f.stub.PvtState)
+	return f
+}
+
+// legacyChaincode runs the issuer-list update issueCertificate used to run
+// (appendCertID <certID>), kept for comparison
+type legacyChaincode struct{}
+
+func (legacyChaincode) Init(stub shim.ChaincodeStubInterface) pb.Response {
+	return shim.Success(nil)
+}
+
+func (legacyChaincode) Invoke(stub shim.ChaincodeStubInterface) pb.Response {
+	_, args := stub.GetFunctionAndParameters()
+
+	var issuerList IssuerList
+	err := getState(stub, ISSUER_LIST, &issuerList)
+	if err != nil {
+		return errorResponse(err)
+	}
+	for i, issuerSum := range issuerList.IssuerSummary {
+		if issuerSum.Email == testIssuer1 {
+			issuerList.IssuerSummary[i].CertIDs = append(issuerSum.CertIDs, args[0])
+		}
+	}
+
+	err = marshalAndPutState(stub, issuerList, ISSUER_LIST)
+	if err != nil {
+		return errorResponse(err)
+	}
+	return shim.Success(nil)
+}
+
+func benchRecipient(email string) map[string][]byte {
+	return cctest.Transient(RECIPIENT_TRANSIENT_KEY, RecipientPrivateDetails{
+		Email: email, Name: "Student", PublicKey: "ecdsa-koblitz-pubkey:test", Salt: testSalt,
+	})
+}
+
+// writeSetSize returns the bytes written by a transaction
+func writeSetSize(rwset *cctest.RWSet) int {
+	size := 0
+	for _, w := range rwset.Writes {
+		size += len(w.Key) + len(w.Value)
+	}
+	return size
+}
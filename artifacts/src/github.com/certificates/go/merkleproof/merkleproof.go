@@ -0,0 +1,208 @@
+// Package merkleproof builds and checks Blockcerts MerkleProof2017 proofs of
+// certificate hashes, extended with an issuer signature of the Merkle root.
+//
+// A batch of certificate hashes (the leaves) is hashed pairwise,
+// sha256(left || right), up to the Merkle root; the last node of a level
+// with an odd number of nodes is promoted to the next level. The issuer
+// signs the root with its ECDSA key, so a verifier holding the issuer key
+// can check that a certificate was issued, without access to the peers. The
+// signature is only checked against the keys of the certificate issuer:
+//
+//	proofs, err := merkleproof.Build(hashes)
+//	err = merkleproof.Sign(proofs, key)
+//	...
+//	err = merkleproof.Check(proof, certHash)
+//	err = merkleproof.CheckSignature(proof, bundle, cert.Badge.Issuer.Id)
+package merkleproof
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// Proof is a Blockcerts MerkleProof2017 signature, extended with the issuer
+// signature of the Merkle root
+type Proof struct {
+	Type       []string `json:"type"`
+	TargetHash string   `json:"targetHash"` // hex, the certificate hash
+	MerkleRoot string   `json:"merkleRoot"` // hex
+	Proof      []Step   `json:"proof"`
+	// IssuerSignature is the base64 ASN.1 ECDSA signature of
+	// sha256(merkleRoot bytes)
+	IssuerSignature string `json:"issuerSignature,omitempty"`
+}
+
+// Step is the sibling hash (hex) on the left or the right
+type Step struct {
+	Left  string `json:"left,omitempty"`
+	Right string `json:"right,omitempty"`
+}
+
+// Build returns the proof of each certificate hash (sha256$<hex> or hex),
+// in order
+func Build(hashes []string) ([]*Proof, error) {
+	if len(hashes) == 0 {
+		return nil, errors.New("no certificate hash")
+	}
+
+	level := make([][]byte, len(hashes))
+	for i, hash := range hashes {
+		leaf, err := hex.DecodeString(strings.TrimPrefix(hash, "sha256$"))
+		if err != nil || len(leaf) != sha256.Size {
+			return nil, fmt.Errorf("invalid certificate hash %q", hash)
+		}
+		level[i] = leaf
+	}
+
+	proofs := make([]*Proof, len(hashes))
+	// positions of the leaves in the current level
+	positions := make([]int, len(hashes))
+	for i := range hashes {
+		proofs[i] = &Proof{
+			Type:       []string{"MerkleProof2017", "Extension"},
+			TargetHash: hex.EncodeToString(level[i]),
+			Proof:      []Step{},
+		}
+		positions[i] = i
+	}
+
+	for len(level) > 1 {
+		for i, position := range positions {
+			switch {
+			case position%2 == 1:
+				proofs[i].Proof = append(proofs[i].Proof, Step{Left: hex.EncodeToString(level[position-1])})
+			case position+1 < len(level):
+				proofs[i].Proof = append(proofs[i].Proof, Step{Right: hex.EncodeToString(level[position+1])})
+			}
+			positions[i] = position / 2
+		}
+
+		next := make([][]byte, 0, (len(level)+1)/2)
+		for i := 0; i < len(level); i += 2 {
+			if i+1 == len(level) {
+				next = append(next, level[i])
+				continue
+			}
+			sum := sha256.Sum256(append(append([]byte{}, level[i]...), level[i+1]...))
+			next = append(next, sum[:])
+		}
+		level = next
+	}
+
+	root := hex.EncodeToString(level[0])
+	for _, proof := range proofs {
+		proof.MerkleRoot = root
+	}
+	return proofs, nil
+}
+
+// Sign sets the issuer signature of proofs, which share a Merkle root
+func Sign(proofs []*Proof, key *ecdsa.PrivateKey) error {
+	if len(proofs) == 0 {
+		return errors.New("no proof")
+	}
+	root, err := hex.DecodeString(proofs[0].MerkleRoot)
+	if err != nil {
+		return errors.New("invalid Merkle root: " + err.Error())
+	}
+
+	digest := sha256.Sum256(root)
+	r, s, err := ecdsa.Sign(rand.Reader, key, digest[:])
+	if err != nil {
+		return err
+	}
+	der, err := asn1.Marshal(struct{ R, S *big.Int }{r, s})
+	if err != nil {
+		return err
+	}
+
+	signature := base64.StdEncoding.EncodeToString(der)
+	for _, proof := range proofs {
+		if proof.MerkleRoot != proofs[0].MerkleRoot {
+			return errors.New("proofs have different Merkle roots")
+		}
+		proof.IssuerSignature = signature
+	}
+	return nil
+}
+
+// Check checks that the proof target is the certificate hash (sha256$<hex>)
+// and that hashing it with the proof siblings leads to the Merkle root
+func Check(proof *Proof, certHash string) error {
+	if proof.TargetHash != strings.TrimPrefix(certHash, "sha256$") {
+		return errors.New("proof target " + proof.TargetHash + " is not the certificate hash")
+	}
+
+	node, err := hex.DecodeString(proof.TargetHash)
+	if err != nil {
+		return errors.New("invalid target hash: " + err.Error())
+	}
+	for i, step := range proof.Proof {
+		sibling, err := hex.DecodeString(step.Left + step.Right)
+		if err != nil || (step.Left == "") == (step.Right == "") {
+			return fmt.Errorf("invalid proof step %d", i)
+		}
+		var sum [sha256.Size]byte
+		if step.Left != "" {
+			sum = sha256.Sum256(append(sibling, node...))
+		} else {
+			sum = sha256.Sum256(append(node, sibling...))
+		}
+		node = sum[:]
+	}
+
+	if hex.EncodeToString(node) != proof.MerkleRoot {
+		return errors.New("proof leads to " + hex.EncodeToString(node) + ", not to the Merkle root")
+	}
+	return nil
+}
+
+// KeyBundle holds the public keys of issuers, by issuer ID
+type KeyBundle map[string][]*ecdsa.PublicKey
+
+// Add adds the key of an issuer
+func (b KeyBundle) Add(issuerID string, key *ecdsa.PublicKey) {
+	b[issuerID] = append(b[issuerID], key)
+}
+
+// CheckSignature checks that the Merkle root is signed by a key of
+// issuerID, the issuer of the certificate. Keys of other issuers in the
+// bundle are ignored.
+func CheckSignature(proof *Proof, bundle KeyBundle, issuerID string) error {
+	keys := bundle[issuerID]
+	if issuerID == "" || len(keys) == 0 {
+		return errors.New("no key of issuer '" + issuerID + "' in the bundle")
+	}
+	if proof.IssuerSignature == "" {
+		return errors.New("Merkle proof has no issuer signature")
+	}
+	root, err := hex.DecodeString(proof.MerkleRoot)
+	if err != nil {
+		return errors.New("invalid Merkle root: " + err.Error())
+	}
+	der, err := base64.StdEncoding.DecodeString(proof.IssuerSignature)
+	if err != nil {
+		return errors.New("invalid issuer signature: " + err.Error())
+	}
+	var signature struct{ R, S *big.Int }
+	_, err = asn1.Unmarshal(der, &signature)
+	if err != nil {
+		return errors.New("invalid issuer signature: " + err.Error())
+	}
+
+	digest := sha256.Sum256(root)
+	for _, key := range keys {
+		if ecdsa.Verify(key, digest[:], signature.R, signature.S) {
+			return nil
+		}
+	}
+	return errors.New("Merkle root is not signed by a key of issuer " + issuerID)
+}
@@ -0,0 +1,130 @@
+package merkleproof
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"testing"
+)
+
+func testHashes(n int) []string {
+	hashes := make([]string, n)
+	for i := range hashes {
+		sum := sha256.Sum256([]byte(strconv.Itoa(i)))
+		hashes[i] = "sha256$" + hex.EncodeToString(sum[:])
+	}
+	return hashes
+}
+
+func TestBuild(t *testing.T) {
+	for _, n := range []int{1, 2, 3, 4, 5, 7, 8, 13} {
+		t.Run(strconv.Itoa(n)+" leaves", func(t *testing.T) {
+			hashes := testHashes(n)
+			proofs, err := Build(hashes)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i, proof := range proofs {
+				if proof.MerkleRoot != proofs[0].MerkleRoot {
+					t.Errorf("leaf %d: root %s, expected %s", i, proof.MerkleRoot, proofs[0].MerkleRoot)
+				}
+				if err := Check(proof, hashes[i]); err != nil {
+					t.Errorf("leaf %d: %s", i, err)
+				}
+				if err := Check(proof, hashes[(i+1)%n]); n > 1 && err == nil {
+					t.Errorf("leaf %d: proof accepted for another hash", i)
+				}
+			}
+		})
+	}
+}
+
+func TestBuildRoot(t *testing.T) {
+	hashes := testHashes(3)
+	proofs, err := Build(hashes)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	leaf := func(i int) []byte {
+		b, _ := hex.DecodeString(hashes[i][len("sha256$"):])
+		return b
+	}
+	// the third leaf is promoted
+	left := sha256.Sum256(append(leaf(0), leaf(1)...))
+	root := sha256.Sum256(append(left[:], leaf(2)...))
+	if proofs[0].MerkleRoot != hex.EncodeToString(root[:]) {
+		t.Errorf("expected root %x, got %s", root, proofs[0].MerkleRoot)
+	}
+	if len(proofs[2].Proof) != 1 || proofs[2].Proof[0].Left != hex.EncodeToString(left[:]) {
+		t.Errorf("expected the promoted leaf to have a single left step, got %+v", proofs[2].Proof)
+	}
+}
+
+func TestBuildInvalid(t *testing.T) {
+	for _, hashes := range [][]string{nil, {"sha256$abc"}, {"not hex"}} {
+		if _, err := Build(hashes); err == nil {
+			t.Errorf("expected an error for %q", hashes)
+		}
+	}
+}
+
+func TestSignature(t *testing.T) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	other, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	bundle := KeyBundle{}
+	bundle.Add("issuer1@org1.example.com", &other.PublicKey)
+	bundle.Add("issuer1@org1.example.com", &key.PublicKey)
+	bundle.Add("issuer2@org2.example.com", &other.PublicKey)
+
+	proofs, err := Build(testHashes(4))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := CheckSignature(proofs[0], bundle, "issuer1@org1.example.com"); err == nil {
+		t.Error("expected an error for an unsigned proof")
+	}
+	if err := Sign(proofs, key); err != nil {
+		t.Fatal(err)
+	}
+
+	for i, proof := range proofs {
+		if err := CheckSignature(proof, bundle, "issuer1@org1.example.com"); err != nil {
+			t.Errorf("proof %d: %s", i, err)
+		}
+		if err := CheckSignature(proof, bundle, "issuer2@org2.example.com"); err == nil {
+			t.Errorf("proof %d: signature accepted for another key", i)
+		}
+		if err := CheckSignature(proof, bundle, "issuer3@org1.example.com"); err == nil {
+			t.Errorf("proof %d: signature accepted without a key of the issuer", i)
+		}
+	}
+}
+
+// A valid signature of another issuer of the bundle is rejected
+func TestSignatureOtherIssuer(t *testing.T) {
+	key1, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	key2, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	bundle := KeyBundle{}
+	bundle.Add("issuer1@org1.example.com", &key1.PublicKey)
+	bundle.Add("issuer2@org2.example.com", &key2.PublicKey)
+
+	proofs, err := Build(testHashes(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Sign(proofs, key2); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := CheckSignature(proofs[0], bundle, "issuer2@org2.example.com"); err != nil {
+		t.Fatal(err)
+	}
+	if err := CheckSignature(proofs[0], bundle, "issuer1@org1.example.com"); err == nil {
+		t.Error("expected the signature of issuer2 to be rejected for issuer1")
+	}
+}
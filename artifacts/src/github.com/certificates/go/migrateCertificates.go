@@ -0,0 +1,98 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/certificates/go/ccerrors"
+	"github.com/certificates/go/events"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	pb "github.com/hyperledger/fabric/protos/peer"
+)
+
+// Progress of migrateCertificates and renewRecipientData, the object of
+// their MutationResult
+type MigrationPage struct {
+	Scanned int32 `json:"scanned"`
+	// First key of the next call, empty after the last certificate
+	NextKey string `json:"nextKey"`
+}
+
+// Function that rewrites legacy certificates (embedded badge) in the stored
+// form (badge reference, see utils_badgeversion.go). Admins only.
+//
+// Arguments: 1) pageSize, 2) startKey (empty for the first certificate).
+// Call it with the nextKey of the previous call until it is empty.
+// Certificates keep their hash, and their key-level endorsement policy: the
+// issuing organizations must endorse.
+//
+// Fabric rejects writes after a paginated query, so the certificates are
+// read with a plain range query, stopped after pageSize keys.
+func (t *SimpleChaincode) migrateCertificates(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+
+	logger.Infof("Action: migrate Certificates")
+
+	if len(args) != 2 {
+		return errorResponse(ccerrors.New(ccerrors.InvalidArgument, "Incorrect number of arguments. Expecting 2: 1) pageSize, 2) startKey"))
+	}
+
+	err := checkUserAttr(stub, "admin", "true")
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	pageSize, startKey, err := parsePaginationArgs(args[0], args[1])
+	if err != nil {
+		return errorResponse(err)
+	}
+	if startKey == "" {
+		startKey = CERT_PREFIX
+	}
+	if !strings.HasPrefix(startKey, CERT_PREFIX) {
+		return errorResponse(ccerrors.New(ccerrors.InvalidArgument, "startKey must be a certificate key ("+CERT_PREFIX+"...)"))
+	}
+
+	resultsIterator, err := stub.GetStateByRange(startKey, prefixRangeEnd(CERT_PREFIX))
+	if err != nil {
+		return errorResponse(ccerrors.New(ccerrors.Internal, "Failed to get certificates range: "+err.Error()))
+	}
+	defer resultsIterator.Close()
+
+	var progress MigrationPage
+	var updated []string
+	for resultsIterator.HasNext() {
+		record, err := resultsIterator.Next()
+		if err != nil {
+			return errorResponse(err)
+		}
+		if progress.Scanned == pageSize {
+			progress.NextKey = record.Key
+			break
+		}
+		progress.Scanned++
+
+		if isBadgeReference(record.Value) {
+			continue
+		}
+
+		var cert Certificate
+		err = decodeState(record.Key, record.Value, &cert)
+		if err != nil {
+			return errorResponse(err)
+		}
+
+		err = putCertificateState(stub, cert)
+		if err != nil {
+			return errorResponse(err)
+		}
+		updated = append(updated, cert.Id)
+	}
+
+	// one bulk event for the page (see package events)
+	err = emitEvent(stub, events.CertificatesMigrated, events.CertificatesMigratedPayload{CertIDs: updated})
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	logger.Infof("Migrated %d of %d certificates", len(updated), progress.Scanned)
+	return mutationResponse(stub, nil, updated, progress)
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/certificates/go/ccerrors"
+	"github.com/certificates/go/cctest"
+	"github.com/certificates/go/events"
+)
+
+func TestMigrateCertificates(t *testing.T) {
+	f := newFixture(t)
+
+	// the certificate in the legacy form, with its badge embedded
+	var legacy Certificate
+	fatalOnError(t, decodeCertificate(f.stub, f.certID, f.stub.State[f.certID], &legacy))
+	legacyBytes, err := json.Marshal(legacy)
+	fatalOnError(t, err)
+	f.stub.State[f.certID] = legacyBytes
+
+	var mutation MutationResult
+	var verdict VerificationVerdict
+	f.stub.Run(t, []cctest.Case{
+		{Name: "arguments", Identity: f.admin, Function: "migrateCertificates", Args: []string{"10"}, Code: ccerrors.InvalidArgument},
+		{Name: "not admin", Identity: f.issuer1, Function: "migrateCertificates", Args: []string{"10", ""}, Code: ccerrors.Forbidden},
+		{Name: "invalid startKey", Identity: f.admin, Function: "migrateCertificates", Args: []string{"10", BADGE_PREFIX}, Code: ccerrors.InvalidArgument},
+		{Name: "past the last certificate", Identity: f.admin, Function: "migrateCertificates", Args: []string{"10", f.certID + "~"},
+			Check: cctest.Decode(&mutation, func() error {
+				return expect(len(mutation.Updated) == 0, "no certificate migrated", mutation.Updated)
+			})},
+		{Name: "migrated", Identity: f.admin, Function: "migrateCertificates", Args: []string{"10", ""},
+			Check: cctest.Decode(&mutation, func() error {
+				var migrated events.CertificatesMigratedPayload
+				if err := expectEvent(f.stub, events.CertificatesMigrated, &migrated); err != nil {
+					return err
+				}
+				return expect(len(mutation.Updated) == 1 && mutation.Updated[0] == f.certID &&
+					len(migrated.CertIDs) == 1, "certificate "+f.certID+" migrated", migrated)
+			})},
+		{Name: "already migrated", Identity: f.admin, Function: "migrateCertificates", Args: []string{"10", ""},
+			Check: cctest.Decode(&mutation, func() error {
+				return expect(len(mutation.Updated) == 0, "no certificate migrated", mutation.Updated)
+			})},
+		{Name: "verify", Identity: f.issuer2, Function: "verifyCertificate", Args: []string{f.certID},
+			Check: cctest.Decode(&verdict, func() error {
+				return expect(isBadgeReference(f.stub.State[f.certID]) && verdict.Valid && verdict.Integrity.Stored == legacy.Hash,
+					"valid migrated certificate", verdict)
+			})},
+	})
+}
+
+func TestMigrateCertificatesPages(t *testing.T) {
+	f := newFixture(t)
+	f.invoke(t, f.issuer1, cctest.Transient(RECIPIENT_TRANSIENT_KEY, RecipientPrivateDetails{Email: "other@example.com", Salt: testSalt}),
+		"issueCertificate", certArgs("data-science")...)
+
+	var mutation struct {
+		MutationResult
+		Progress MigrationPage `json:"object"`
+	}
+	var nextKey string
+	f.stub.Run(t, []cctest.Case{
+		{Name: "first page", Identity: f.admin, Function: "migrateCertificates", Args: []string{"1", ""},
+			Check: cctest.Decode(&mutation, func() error {
+				nextKey = mutation.Progress.NextKey
+				return expect(mutation.Progress.Scanned == 1 && nextKey != "", "1 certificate and the next key", mutation.Progress)
+			})},
+	})
+	f.stub.Run(t, []cctest.Case{
+		{Name: "last page", Identity: f.admin, Function: "migrateCertificates", Args: []string{"1", nextKey},
+			Check: cctest.Decode(&mutation, func() error {
+				return expect(mutation.Progress.Scanned == 1 && mutation.Progress.NextKey == "", "1 certificate, last page", mutation.Progress)
+			})},
+	})
+}
@@ -0,0 +1,237 @@
+// Package mspidentity mints client identities of Fabric MSPs: X.509
+// certificates carrying Fabric CA attributes (role, email...), wrapped in
+// serialized MSP identities, like the creator of a transaction proposal.
+//
+// The CA is either generated or loaded from a cryptogen organization:
+//
+//	ca, _ := mspidentity.LoadCA("Org1MSP", caCertPEM, caKeyPEM)
+//	issuer, _ := ca.NewIdentity("issuer1@org1.example.com", map[string]string{
+//		"role": "university", "email": "issuer1@org1.example.com"}, 365*24*time.Hour)
+//
+// It is used by cmd/ccidentity and by the cctest harness.
+package mspidentity
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/common/attrmgr"
+	"github.com/hyperledger/fabric/protos/msp"
+)
+
+// CA is a certificate authority of an MSP
+type CA struct {
+	MSPID   string
+	Cert    *x509.Certificate
+	Key     *ecdsa.PrivateKey
+	CertPEM []byte
+}
+
+// Identity is a client identity issued by a CA
+type Identity struct {
+	MSPID   string
+	Cert    *x509.Certificate
+	Key     *ecdsa.PrivateKey
+	CertPEM []byte
+	// Creator is the serialized MSP identity returned by GetCreator
+	Creator []byte
+}
+
+// LoadCA loads a CA from its PEM certificate and PKCS#8 (or SEC 1) EC
+// private key, e.g. the ca folder of a cryptogen organization
+func LoadCA(mspID string, certPEM, keyPEM []byte) (*CA, error) {
+	certBlock, _ := pem.Decode(certPEM)
+	if certBlock == nil {
+		return nil, errors.New("CA certificate is not PEM encoded")
+	}
+	cert, err := x509.ParseCertificate(certBlock.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	keyBlock, _ := pem.Decode(keyPEM)
+	if keyBlock == nil {
+		return nil, errors.New("CA private key is not PEM encoded")
+	}
+	key, err := parseECPrivateKey(keyBlock.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	pub, ok := cert.PublicKey.(*ecdsa.PublicKey)
+	if !ok || pub.X.Cmp(key.PublicKey.X) != 0 || pub.Y.Cmp(key.PublicKey.Y) != 0 {
+		return nil, errors.New("CA private key doesn't match the CA certificate")
+	}
+
+	return &CA{MSPID: mspID, Cert: cert, Key: key, CertPEM: certPEM}, nil
+}
+
+// NewCA creates a self-signed CA for mspID, valid for validity
+func NewCA(mspID string, validity time.Duration) (*CA, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	template, err := newTemplate(pkix.Name{CommonName: "ca." + mspID}, validity)
+	if err != nil {
+		return nil, err
+	}
+	template.IsCA = true
+	template.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageCRLSign | x509.KeyUsageDigitalSignature
+	template.SubjectKeyId = SubjectKeyID(&key.PublicKey)
+
+	cert, certPEM, err := createCert(template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, err
+	}
+
+	return &CA{MSPID: mspID, Cert: cert, Key: key, CertPEM: certPEM}, nil
+}
+
+// NewIdentity issues a client certificate for commonName with the given
+// Fabric CA attributes, valid for validity. Like cryptogen, the subject
+// keeps the location of the CA. The certificate never outlives the CA: its
+// NotAfter is clamped to the CA one.
+func (ca *CA) NewIdentity(commonName string, attrs map[string]string, validity time.Duration) (*Identity, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	template, err := newTemplate(pkix.Name{
+		Country:    ca.Cert.Subject.Country,
+		Province:   ca.Cert.Subject.Province,
+		Locality:   ca.Cert.Subject.Locality,
+		CommonName: commonName,
+	}, validity)
+	if err != nil {
+		return nil, err
+	}
+	template.KeyUsage = x509.KeyUsageDigitalSignature
+	if template.NotAfter.After(ca.Cert.NotAfter) {
+		template.NotAfter = ca.Cert.NotAfter
+	}
+
+	if len(attrs) > 0 {
+		err = attrmgr.New().AddAttributesToCert(&attrmgr.Attributes{Attrs: attrs}, template)
+		if err != nil {
+			return nil, err
+		}
+		// attrmgr adds the extension to Extensions, which x509 ignores when
+		// signing (the CA server copies them as well)
+		template.ExtraExtensions = template.Extensions
+	}
+
+	cert, certPEM, err := createCert(template, ca.Cert, &key.PublicKey, ca.Key)
+	if err != nil {
+		return nil, err
+	}
+
+	creator, err := SerializeIdentity(ca.MSPID, certPEM)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Identity{MSPID: ca.MSPID, Cert: cert, Key: key, CertPEM: certPEM, Creator: creator}, nil
+}
+
+// SerializeIdentity wraps a PEM certificate in a serialized MSP identity
+func SerializeIdentity(mspID string, certPEM []byte) ([]byte, error) {
+	if mspID == "" {
+		return nil, errors.New("MSP ID must be a non-empty string")
+	}
+	return proto.Marshal(&msp.SerializedIdentity{Mspid: mspID, IdBytes: certPEM})
+}
+
+// SubjectKeyID returns the key identifier used by cryptogen and the Fabric
+// BCCSP: SHA-256 of the uncompressed public key point. Its hex encoding
+// names the private key file in an MSP keystore.
+func SubjectKeyID(pub *ecdsa.PublicKey) []byte {
+	sum := sha256.Sum256(elliptic.Marshal(pub.Curve, pub.X, pub.Y))
+	return sum[:]
+}
+
+// KeyPEM returns the PKCS#8 PEM encoding of the identity private key
+func (id *Identity) KeyPEM() ([]byte, error) {
+	der, err := x509.MarshalPKCS8PrivateKey(id.Key)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+}
+
+func parseECPrivateKey(der []byte) (*ecdsa.PrivateKey, error) {
+	if key, err := x509.ParseECPrivateKey(der); err == nil {
+		return key, nil
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		return nil, err
+	}
+	ecKey, ok := key.(*ecdsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("CA private key is not an EC key")
+	}
+	return ecKey, nil
+}
+
+func newTemplate(subject pkix.Name, validity time.Duration) (*x509.Certificate, error) {
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, err
+	}
+
+	notBefore := time.Now().Add(-time.Hour)
+	return &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               subject,
+		NotBefore:             notBefore,
+		NotAfter:              notBefore.Add(validity),
+		BasicConstraintsValid: true,
+	}, nil
+}
+
+func createCert(template, parent *x509.Certificate, pub *ecdsa.PublicKey, signer *ecdsa.PrivateKey) (*x509.Certificate, []byte, error) {
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, pub, signer)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return cert, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), nil
+}
+
+// IdentityFromCert wraps an existing certificate (e.g. the signcerts of an
+// MSP folder) in an Identity, without private key
+func IdentityFromCert(mspID string, certPEM []byte) (*Identity, error) {
+	block, _ := pem.Decode(certPEM)
+	if block == nil {
+		return nil, errors.New("certificate is not PEM encoded")
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	creator, err := SerializeIdentity(mspID, certPEM)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Identity{MSPID: mspID, Cert: cert, CertPEM: certPEM, Creator: creator}, nil
+}
@@ -0,0 +1,42 @@
+package mspidentity
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric/common/attrmgr"
+)
+
+func TestNewIdentity(t *testing.T) {
+	ca, err := NewCA("Org1MSP", 24*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	identity, err := ca.NewIdentity("issuer1@org1.example.com", map[string]string{"role": "university"}, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !identity.Cert.NotAfter.Before(ca.Cert.NotAfter) {
+		t.Errorf("expected the identity to expire before the CA, got %s", identity.Cert.NotAfter)
+	}
+	attrs, err := attrmgr.New().GetAttributesFromCert(identity.Cert)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if role, ok, _ := attrs.Value("role"); !ok || role != "university" {
+		t.Errorf("expected role=university, got %q", role)
+	}
+
+	// The identity never outlives its CA
+	identity, err = ca.NewIdentity("issuer1@org1.example.com", nil, 365*24*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !identity.Cert.NotAfter.Equal(ca.Cert.NotAfter) {
+		t.Errorf("expected NotAfter clamped to %s, got %s", ca.Cert.NotAfter, identity.Cert.NotAfter)
+	}
+	if err := identity.Cert.CheckSignatureFrom(ca.Cert); err != nil {
+		t.Errorf("expected the identity signed by the CA: %v", err)
+	}
+}
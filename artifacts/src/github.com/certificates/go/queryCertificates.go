@@ -0,0 +1,350 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"regexp"
+
+	"github.com/certificates/go/canonicaljson"
+	"github.com/certificates/go/ccerrors"
+	"github.com/certificates/go/validation"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	pb "github.com/hyperledger/fabric/protos/peer"
+)
+
+// Rich query selector accepted by queryCertificates and queryBadges.
+// Only these fields can be queried, and every query must include at least
+// one indexed field (see META-INF/statedb/couchdb/indexes) so CouchDB never
+// does a full scan.
+//
+// badge is a badge ID, or a slug of the issuer badges: it needs issuer.
+// recipient is the salted hash of the recipient identity (sha256$<hex>, the
+// recipient.identity of the certificate): recipients are hashed with a salt
+// per certificate, so they can't be queried by email.
+type QuerySelector struct {
+	Issuer       string `json:"issuer"`
+	Badge        string `json:"badge"`
+	Recipient    string `json:"recipient"` // hashed identity (sha256$<hex>)
+	IssuedOnFrom string `json:"issuedOnFrom"`
+	IssuedOnTo   string `json:"issuedOnTo"`
+	Status       string `json:"status"` // active, revoked or erased
+}
+
+// Query certificates using a QuerySelector (JSON), paginated
+func (t *SimpleChaincode) queryCertificates(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+
+	if len(args) != 3 {
+		return errorResponse(ccerrors.New(ccerrors.InvalidArgument, "Incorrect number of arguments. Expecting 3: 1) selector (JSON), 2) pageSize, 3) bookmark"))
+	}
+
+	selector, err := parseQuerySelector(args[0])
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	pageSize, bookmark, err := parsePaginationArgs(args[1], args[2])
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	queryString, err := buildCertificatesQuery(selector)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	page, err := getQueryResultPage(stub, queryString, pageSize, bookmark)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	err = joinCertificatesPage(stub, &page)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	return marshalPage(page)
+}
+
+// List all certificates by key, paginated
+func (t *SimpleChaincode) listCertificates(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+
+	if len(args) != 2 {
+		return errorResponse(ccerrors.New(ccerrors.InvalidArgument, "Incorrect number of arguments. Expecting 2: 1) pageSize, 2) bookmark"))
+	}
+
+	pageSize, bookmark, err := parsePaginationArgs(args[0], args[1])
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	page, err := getPrefixRangePage(stub, CERT_PREFIX, pageSize, bookmark)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	err = joinCertificatesPage(stub, &page)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	return marshalPage(page)
+}
+
+// Query badges using a QuerySelector (JSON), paginated.
+// Only issuer and badge fields apply to badges.
+func (t *SimpleChaincode) queryBadges(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+
+	if len(args) != 3 {
+		return errorResponse(ccerrors.New(ccerrors.InvalidArgument, "Incorrect number of arguments. Expecting 3: 1) selector (JSON), 2) pageSize, 3) bookmark"))
+	}
+
+	selector, err := parseQuerySelector(args[0])
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	pageSize, bookmark, err := parsePaginationArgs(args[1], args[2])
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	queryString, err := buildBadgesQuery(selector)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	page, err := getQueryResultPage(stub, queryString, pageSize, bookmark)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	return marshalPage(page)
+}
+
+// List all badges by key, paginated
+func (t *SimpleChaincode) listBadges(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+
+	if len(args) != 2 {
+		return errorResponse(ccerrors.New(ccerrors.InvalidArgument, "Incorrect number of arguments. Expecting 2: 1) pageSize, 2) bookmark"))
+	}
+
+	pageSize, bookmark, err := parsePaginationArgs(args[0], args[1])
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	page, err := getPrefixRangePage(stub, BADGE_PREFIX, pageSize, bookmark)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	return marshalPage(page)
+}
+
+// List the certificates of an issuer by key, paginated. They replace the
+// legacy IssuerSummary.CertIDs: certificate IDs start with cert:<issuer>/.
+// Arguments: 1) issuer email, 2) pageSize, 3) bookmark
+func (t *SimpleChaincode) listIssuerCertificates(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+
+	if len(args) != 3 {
+		return errorResponse(ccerrors.New(ccerrors.InvalidArgument, "Incorrect number of arguments. Expecting 3: 1) issuer email, 2) pageSize, 3) bookmark"))
+	}
+
+	prefix, err := issuerKeyPrefix(CERT_PREFIX, args[0])
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	pageSize, bookmark, err := parsePaginationArgs(args[1], args[2])
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	page, err := getPrefixRangePage(stub, prefix, pageSize, bookmark)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	err = joinCertificatesPage(stub, &page)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	return marshalPage(page)
+}
+
+// List the badges of an issuer by key, paginated. They replace the legacy
+// IssuerSummary.BagdeIDs: badge IDs start with badge:<issuer>/.
+// Arguments: 1) issuer email, 2) pageSize, 3) bookmark
+func (t *SimpleChaincode) listIssuerBadges(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+
+	if len(args) != 3 {
+		return errorResponse(ccerrors.New(ccerrors.InvalidArgument, "Incorrect number of arguments. Expecting 3: 1) issuer email, 2) pageSize, 3) bookmark"))
+	}
+
+	prefix, err := issuerKeyPrefix(BADGE_PREFIX, args[0])
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	pageSize, bookmark, err := parsePaginationArgs(args[1], args[2])
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	page, err := getPrefixRangePage(stub, prefix, pageSize, bookmark)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	return marshalPage(page)
+}
+
+// Function that returns the prefix of the keys of an issuer (badges or
+// certificates)
+func issuerKeyPrefix(prefix, issuer string) (string, error) {
+	v := validation.New()
+	v.Email("issuer", issuer)
+	if err := v.Err(); err != nil {
+		return "", err
+	}
+	return prefix + issuer + "/", nil
+}
+
+var hashedIdentityPattern = regexp.MustCompile(`^sha256\$[0-9a-f]{64}$`)
+
+// Function that decodes a QuerySelector, rejecting unknown fields
+func parseQuerySelector(selectorString string) (QuerySelector, error) {
+	var selector QuerySelector
+
+	decoder := json.NewDecoder(bytes.NewReader([]byte(selectorString)))
+	decoder.DisallowUnknownFields()
+
+	err := decoder.Decode(&selector)
+	if err != nil {
+		return selector, ccerrors.New(ccerrors.InvalidArgument, "Invalid selector: "+err.Error())
+	}
+
+	v := validation.New()
+	v.Key("issuer", selector.Issuer)
+	v.Key("badge", selector.Badge)
+	if selector.Badge != "" && selector.Issuer == "" {
+		v.Add("badge", "needs issuer")
+	}
+	if selector.Recipient != "" && !hashedIdentityPattern.MatchString(selector.Recipient) {
+		v.Add("recipient", "must be a hashed identity (sha256$<hex>)")
+	}
+	v.Key("issuedOnFrom", selector.IssuedOnFrom)
+	v.Key("issuedOnTo", selector.IssuedOnTo)
+	if selector.Status != "" && selector.Status != "active" && selector.Status != "revoked" && selector.Status != "erased" {
+		v.Add("status", "must be one of 'active', 'revoked' or 'erased'")
+	}
+
+	return selector, v.Err()
+}
+
+// Function that builds the CouchDB query for certificates, using the index
+// of the most selective field provided
+func buildCertificatesQuery(selector QuerySelector) (string, error) {
+	couchSelector := map[string]interface{}{
+		"type": "Assertion",
+	}
+	var index []string
+
+	if selector.IssuedOnFrom != "" || selector.IssuedOnTo != "" {
+		issuedOn := map[string]string{}
+		if selector.IssuedOnFrom != "" {
+			issuedOn["$gte"] = selector.IssuedOnFrom
+		}
+		if selector.IssuedOnTo != "" {
+			issuedOn["$lte"] = selector.IssuedOnTo
+		}
+		couchSelector["issuedOn"] = issuedOn
+		index = []string{"indexCertIssuedOnDoc", "indexCertIssuedOn"}
+	}
+	if selector.Issuer != "" {
+		couchSelector["badge.issuer.id"] = selector.Issuer
+		index = []string{"indexCertIssuerDoc", "indexCertIssuer"}
+	}
+	if selector.Badge != "" {
+		couchSelector["badge.id"] = resolveBadgeID(selector.Issuer, selector.Badge)
+		index = []string{"indexCertBadgeDoc", "indexCertBadge"}
+	}
+	if selector.Recipient != "" {
+		couchSelector["recipient.identity"] = selector.Recipient
+		index = []string{"indexCertRecipientDoc", "indexCertRecipient"}
+	}
+
+	if index == nil {
+		return "", ccerrors.New(ccerrors.InvalidArgument, "Selector must include issuer, badge, recipient or an issuedOn range")
+	}
+
+	// revoked and subjectErased are omitted when false
+	switch selector.Status {
+	case "active":
+		couchSelector["revoked"] = map[string]bool{"$exists": false}
+		couchSelector["subjectErased"] = map[string]bool{"$exists": false}
+	case "revoked":
+		couchSelector["revoked"] = true
+	case "erased":
+		couchSelector["subjectErased"] = true
+	}
+
+	return marshalCouchQuery(couchSelector, index)
+}
+
+// Function that builds the CouchDB query for badges
+func buildBadgesQuery(selector QuerySelector) (string, error) {
+	if selector.Recipient != "" || selector.IssuedOnFrom != "" || selector.IssuedOnTo != "" || selector.Status != "" {
+		return "", ccerrors.New(ccerrors.InvalidArgument, "Badges can only be queried by issuer and badge")
+	}
+	if selector.Issuer == "" {
+		return "", ccerrors.New(ccerrors.InvalidArgument, "Selector must include issuer")
+	}
+
+	couchSelector := map[string]interface{}{
+		"type":      "BadgeClass",
+		"issuer.id": selector.Issuer,
+	}
+	if selector.Badge != "" {
+		couchSelector["_id"] = resolveBadgeID(selector.Issuer, selector.Badge)
+	}
+
+	return marshalCouchQuery(couchSelector, []string{"indexBadgeIssuerDoc", "indexBadgeIssuer"})
+}
+
+func marshalCouchQuery(couchSelector map[string]interface{}, index []string) (string, error) {
+	query := map[string]interface{}{
+		"selector":  couchSelector,
+		"use_index": index,
+	}
+
+	out, err := json.Marshal(query)
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
+
+// Function that joins the recipient private details of a page of
+// certificates when the caller's org owns them
+func joinCertificatesPage(stub shim.ChaincodeStubInterface, page *PagedResponse) error {
+	for i := range page.Records {
+		var cert Certificate
+		err := decodeCertificate(stub, page.Records[i].Key, page.Records[i].Record, &cert)
+		if err != nil {
+			return err
+		}
+
+		err = joinRecipientPrivateDetails(stub, &cert)
+		if err != nil {
+			return err
+		}
+
+		page.Records[i].Record, err = canonicaljson.Marshal(cert)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/certificates/go/ccerrors"
+	"github.com/certificates/go/cctest"
+)
+
+func TestListCertificates(t *testing.T) {
+	f := newFixture(t)
+
+	var page PagedResponse
+	f.stub.Run(t, []cctest.Case{
+		{Name: "page size", Identity: f.issuer1, Function: "listCertificates", Args: []string{"0", ""}, Code: ccerrors.InvalidArgument},
+		{Name: "list", Identity: f.issuer1, Function: "listCertificates", Args: []string{"10", ""},
+			Check: cctest.Decode(&page, func() error {
+				return expect(len(page.Records) == 1 && page.Records[0].Key == f.certID && page.Bookmark == "", "1 certificate", page)
+			})},
+	})
+}
+
+func TestListBadges(t *testing.T) {
+	f := newFixture(t)
+
+	var page PagedResponse
+	var bookmark string
+	f.stub.Run(t, []cctest.Case{
+		{Name: "arguments", Identity: f.issuer1, Function: "listBadges", Args: []string{"10"}, Code: ccerrors.InvalidArgument},
+		{Name: "first page", Identity: f.issuer1, Function: "listBadges", Args: []string{"1", ""},
+			Check: cctest.Decode(&page, func() error {
+				bookmark = page.Bookmark
+				return expect(len(page.Records) == 1 && page.Bookmark != "", "1 badge and a bookmark", page)
+			})},
+	})
+	f.stub.Run(t, []cctest.Case{
+		{Name: "last page", Identity: f.issuer1, Function: "listBadges", Args: []string{"1", bookmark},
+			Check: cctest.Decode(&page, func() error {
+				return expect(len(page.Records) == 1 && page.Bookmark == "", "1 badge, last page", page)
+			})},
+	})
+}
+
+// The success of rich queries needs CouchDB, only their validation is tested
+func TestQueryCertificates(t *testing.T) {
+	f := newFixture(t)
+
+	f.stub.Run(t, []cctest.Case{
+		{Name: "arguments", Identity: f.issuer1, Function: "queryCertificates", Args: []string{`{}`}, Code: ccerrors.InvalidArgument},
+		{Name: "invalid selector", Identity: f.issuer1, Function: "queryCertificates",
+			Args: []string{`{"owner":"x"}`, "10", ""}, Code: ccerrors.InvalidArgument},
+		{Name: "invalid recipient", Identity: f.issuer1, Function: "queryCertificates",
+			Args: []string{`{"recipient":"sha256$x"}`, "10", ""}, Code: ccerrors.InvalidArgument},
+		{Name: "recipient email", Identity: f.issuer1, Function: "queryCertificates",
+			Args: []string{`{"recipient":"` + testRecipient + `"}`, "10", ""}, Code: ccerrors.InvalidArgument},
+		{Name: "badge without issuer", Identity: f.issuer1, Function: "queryCertificates",
+			Args: []string{`{"badge":"data-science"}`, "10", ""}, Code: ccerrors.InvalidArgument},
+		{Name: "invalid status", Identity: f.issuer1, Function: "queryCertificates",
+			Args: []string{`{"issuer":"` + testIssuer1 + `","status":"expired"}`, "10", ""}, Code: ccerrors.InvalidArgument},
+	})
+}
+
+func TestBuildCertificatesQuery(t *testing.T) {
+	for _, tc := range []struct {
+		status string
+		query  string
+	}{
+		{"", `{"selector":{"badge.issuer.id":"` + testIssuer1 + `","type":"Assertion"},"use_index":["indexCertIssuerDoc","indexCertIssuer"]}`},
+		{"active", `{"selector":{"badge.issuer.id":"` + testIssuer1 + `","revoked":{"$exists":false},"subjectErased":{"$exists":false},"type":"Assertion"},"use_index":["indexCertIssuerDoc","indexCertIssuer"]}`},
+		{"revoked", `{"selector":{"badge.issuer.id":"` + testIssuer1 + `","revoked":true,"type":"Assertion"},"use_index":["indexCertIssuerDoc","indexCertIssuer"]}`},
+		{"erased", `{"selector":{"badge.issuer.id":"` + testIssuer1 + `","subjectErased":true,"type":"Assertion"},"use_index":["indexCertIssuerDoc","indexCertIssuer"]}`},
+	} {
+		query, err := buildCertificatesQuery(QuerySelector{Issuer: testIssuer1, Status: tc.status})
+		fatalOnError(t, err)
+		if query != tc.query {
+			t.Errorf("status %q: expected %s, got %s", tc.status, tc.query, query)
+		}
+	}
+
+	recipient := hashRecipientIdentity(testRecipient, testSalt)
+	query, err := buildCertificatesQuery(QuerySelector{Recipient: recipient})
+	fatalOnError(t, err)
+	expected := `{"selector":{"recipient.identity":"` + recipient + `","type":"Assertion"},"use_index":["indexCertRecipientDoc","indexCertRecipient"]}`
+	if query != expected {
+		t.Errorf("recipient: expected %s, got %s", expected, query)
+	}
+}
+
+func TestQueryBadges(t *testing.T) {
+	f := newFixture(t)
+
+	f.stub.Run(t, []cctest.Case{
+		{Name: "invalid selector", Identity: f.issuer1, Function: "queryBadges",
+			Args: []string{`{"status":"revoked"}`, "10", ""}, Code: ccerrors.InvalidArgument},
+	})
+}
+
+func TestListIssuerCertificates(t *testing.T) {
+	f := newFixture(t)
+
+	var page PagedResponse
+	f.stub.Run(t, []cctest.Case{
+		{Name: "arguments", Identity: f.issuer1, Function: "listIssuerCertificates", Args: []string{"10", ""}, Code: ccerrors.InvalidArgument},
+		{Name: "invalid issuer", Identity: f.issuer1, Function: "listIssuerCertificates", Args: []string{"issuer1", "10", ""}, Code: ccerrors.InvalidArgument},
+		{Name: "issuer", Identity: f.issuer2, Function: "listIssuerCertificates", Args: []string{testIssuer1, "10", ""},
+			Check: cctest.Decode(&page, func() error {
+				return expect(len(page.Records) == 1 && page.Records[0].Key == f.certID, "1 certificate", page)
+			})},
+		{Name: "issuer without certificates", Identity: f.issuer1, Function: "listIssuerCertificates", Args: []string{testIssuer2, "10", ""},
+			Check: cctest.Decode(&page, func() error {
+				return expect(len(page.Records) == 0, "no certificate", page)
+			})},
+	})
+}
+
+func TestListIssuerBadges(t *testing.T) {
+	f := newFixture(t)
+
+	var page PagedResponse
+	f.stub.Run(t, []cctest.Case{
+		{Name: "invalid issuer", Identity: f.issuer1, Function: "listIssuerBadges", Args: []string{"", "10", ""}, Code: ccerrors.InvalidArgument},
+		{Name: "issuer", Identity: f.issuer1, Function: "listIssuerBadges", Args: []string{testIssuer2, "10", ""},
+			Check: cctest.Decode(&page, func() error {
+				return expect(len(page.Records) == 1 && page.Records[0].Key == f.badge2, "1 badge", page)
+			})},
+	})
+}
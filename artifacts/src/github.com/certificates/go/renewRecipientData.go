@@ -0,0 +1,146 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/certificates/go/ccerrors"
+	"github.com/certificates/go/events"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	pb "github.com/hyperledger/fabric/protos/peer"
+)
+
+// Function that rewrites, unchanged, the recipient private data of the
+// caller's org collection: the pepper, and the recipient details and
+// indexes of a page of certificates. Admins only.
+//
+// The recipient collections have a blockToLive (collections_config.json):
+// peers purge private data blockToLive blocks after it was written, which
+// is how the values deleted by forgetRecipient leave the peers. Live data
+// must be renewed before it expires: every org runs it over all the
+// certificates more often than every blockToLive blocks.
+//
+// Arguments: 1) pageSize, 2) startKey (empty for the first certificate).
+// Call it with the nextKey of the previous call until it is empty.
+//
+// Fabric rejects writes after a private data range query, so the
+// certificates are listed from the public state, like migrateCertificates.
+func (t *SimpleChaincode) renewRecipientData(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+
+	logger.Infof("Action: renew Recipient data")
+
+	if len(args) != 2 {
+		return errorResponse(ccerrors.New(ccerrors.InvalidArgument, "Incorrect number of arguments. Expecting 2: 1) pageSize, 2) startKey"))
+	}
+
+	err := checkUserAttr(stub, "admin", "true")
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	pageSize, startKey, err := parsePaginationArgs(args[0], args[1])
+	if err != nil {
+		return errorResponse(err)
+	}
+	if startKey == "" {
+		startKey = CERT_PREFIX
+	}
+	if !strings.HasPrefix(startKey, CERT_PREFIX) {
+		return errorResponse(ccerrors.New(ccerrors.InvalidArgument, "startKey must be a certificate key ("+CERT_PREFIX+"...)"))
+	}
+
+	collection, err := getRecipientCollection(stub)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	// 1. Renew the pepper, the recipient index keys depend on it
+	// ----------------------------------------------------------
+	var pepper RecipientPepper
+	err = getPrivateState(stub, collection, RECIPIENT_PEPPER_KEY, &pepper)
+	if isNotFound(err) {
+		return errorResponse(ccerrors.New(ccerrors.NotFound, "Recipient pepper of "+collection+" is not set, see setRecipientPepper"))
+	}
+	if err != nil {
+		return errorResponse(err)
+	}
+	_, err = renewPrivateData(stub, collection, RECIPIENT_PEPPER_KEY)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	// 2. Renew the details and index of the recipients of a page of
+	//    certificates
+	// -------------------------------------------------------------
+	resultsIterator, err := stub.GetStateByRange(startKey, prefixRangeEnd(CERT_PREFIX))
+	if err != nil {
+		return errorResponse(ccerrors.New(ccerrors.Internal, "Failed to get certificates range: "+err.Error()))
+	}
+	defer resultsIterator.Close()
+
+	var progress MigrationPage
+	var renewed []string
+	renewedIndexes := make(map[string]bool)
+	for resultsIterator.HasNext() {
+		record, err := resultsIterator.Next()
+		if err != nil {
+			return errorResponse(err)
+		}
+		if progress.Scanned == pageSize {
+			progress.NextKey = record.Key
+			break
+		}
+		progress.Scanned++
+
+		// certificates of other orgs, or erased, have no details
+		details, err := getRecipientPrivateDetails(stub, collection, record.Key)
+		if err != nil {
+			return errorResponse(err)
+		}
+		if details == nil {
+			continue
+		}
+
+		_, err = renewPrivateData(stub, collection, record.Key)
+		if err != nil {
+			return errorResponse(err)
+		}
+
+		indexKey := recipientIndexKey(pepper.Pepper, details.Email)
+		if !renewedIndexes[indexKey] {
+			_, err = renewPrivateData(stub, collection, indexKey)
+			if err != nil {
+				return errorResponse(err)
+			}
+			renewedIndexes[indexKey] = true
+		}
+		renewed = append(renewed, record.Key)
+	}
+
+	// one bulk event for the page (see package events)
+	err = emitEvent(stub, events.RecipientDataRenewed, events.RecipientDataRenewedPayload{CertIDs: renewed})
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	logger.Infof("Renewed the recipient data of %d of %d certificates in %s", len(renewed), progress.Scanned, collection)
+	return mutationResponse(stub, nil, renewed, progress)
+}
+
+// Function that writes back the current value of a private data key, so
+// that it outlives the collection blockToLive. Returns false if the key
+// doesn't exist.
+func renewPrivateData(stub shim.ChaincodeStubInterface, collection, key string) (bool, error) {
+	stateBytes, err := stub.GetPrivateData(collection, key)
+	if err != nil {
+		return false, ccerrors.New(ccerrors.Internal, "Failed to get private data for "+key+" in "+collection+": "+err.Error())
+	}
+	if stateBytes == nil {
+		return false, nil
+	}
+
+	err = stub.PutPrivateData(collection, key, stateBytes)
+	if err != nil {
+		return false, ccerrors.New(ccerrors.Internal, "Failed to put private data for "+key+" in "+collection+": "+err.Error())
+	}
+	return true, nil
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/certificates/go/ccerrors"
+	"github.com/certificates/go/cctest"
+	"github.com/certificates/go/events"
+)
+
+func TestRenewRecipientData(t *testing.T) {
+	f := newFixture(t)
+
+	collection := RECIPIENT_COLLECTION_PREFIX + "Org1MSP"
+	details := f.stub.PvtState[collection][f.certID]
+
+	var mutation struct {
+		MutationResult
+		Progress MigrationPage `json:"object"`
+	}
+	f.stub.Run(t, []cctest.Case{
+		{Name: "arguments", Identity: f.admin, Function: "renewRecipientData", Args: []string{"10"}, Code: ccerrors.InvalidArgument},
+		{Name: "not admin", Identity: f.issuer1, Function: "renewRecipientData", Args: []string{"10", ""}, Code: ccerrors.Forbidden},
+		{Name: "invalid startKey", Identity: f.admin, Function: "renewRecipientData", Args: []string{"10", BADGE_PREFIX}, Code: ccerrors.InvalidArgument},
+		{Name: "renewed", Identity: f.admin, Function: "renewRecipientData", Args: []string{"10", ""},
+			Check: cctest.Decode(&mutation, func() error {
+				var renewed events.RecipientDataRenewedPayload
+				if err := expectEvent(f.stub, events.RecipientDataRenewed, &renewed); err != nil {
+					return err
+				}
+				return expect(len(mutation.Updated) == 1 && mutation.Updated[0] == f.certID && len(renewed.CertIDs) == 1 &&
+					mutation.Progress.Scanned == 1 && mutation.Progress.NextKey == "" &&
+					bytes.Equal(f.stub.PvtState[collection][f.certID], details), "unchanged details of "+f.certID+" renewed", mutation)
+			})},
+		{Name: "erased", Identity: f.admin, Transient: f.recipient, Function: "forgetRecipient"},
+		{Name: "erased not renewed", Identity: f.admin, Function: "renewRecipientData", Args: []string{"10", ""},
+			Check: cctest.Decode(&mutation, func() error {
+				return expect(len(mutation.Updated) == 0, "nothing renewed", mutation.Updated)
+			})},
+	})
+}
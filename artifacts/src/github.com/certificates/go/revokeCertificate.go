@@ -0,0 +1,83 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/certificates/go/ccerrors"
+	"github.com/certificates/go/events"
+	"github.com/certificates/go/validation"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	pb "github.com/hyperledger/fabric/protos/peer"
+)
+
+// Revokes a certificate of one of the caller's badges. The certificate stays
+// in the ledger, flagged as revoked with the reason, and verifyCertificate
+// reports it as not valid.
+//
+// Revocation status isn't part of the certificate hash, so the issued
+// content stays verifiable. The certificate key has the endorsement policy
+// of the issuer's org (see setIssuerEndorsementPolicy).
+func (t *SimpleChaincode) revokeCertificate(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+
+	logger.Infof("Action: revoke Certificate")
+
+	// Issuer email is added as the 1st argument
+	if len(args) != 3 {
+		return errorResponse(ccerrors.New(ccerrors.InvalidArgument, "Incorrect number of arguments. Expecting 2: 1) Certificate ID, 2) Revocation reason"))
+	}
+
+	issuerEmail, certID, reason := args[0], args[1], args[2]
+
+	v := validation.New()
+	v.Key("certificate", certID)
+	v.Name("reason", reason)
+	if err := v.Err(); err != nil {
+		return errorResponse(err)
+	}
+
+	// 1. Check that the certificate exists and is owned by the issuer
+	// ----------------------------------------------------------------
+	var cert Certificate
+	err := getCertificateState(stub, certID, &cert)
+	if isNotFound(err) || !strings.HasPrefix(certID, CERT_PREFIX) {
+		return errorResponse(ccerrors.New(ccerrors.NotFound, "Certificate doesn't exist, aborting"))
+	}
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	if strings.Compare(cert.Badge.Issuer.Id, issuerEmail) != 0 {
+		return errorResponse(ccerrors.New(ccerrors.Forbidden, "Certificate is not issued by "+issuerEmail))
+	}
+	if cert.Revoked {
+		return errorResponse(ccerrors.New(ccerrors.Conflict, "Certificate is already revoked"))
+	}
+
+	// 2. Flag the certificate as revoked
+	// ----------------------------------
+	cert.Revoked = true
+	cert.RevocationReason = reason
+
+	err = putCertificateState(stub, cert)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	err = recordStatDelta(stub, cert, STAT_REVOKED, 1)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	err = emitEvent(stub, events.CertificateRevoked, events.CertificateRevokedPayload{
+		CertID:   cert.Id,
+		BadgeID:  cert.Badge.Id,
+		IssuerID: issuerEmail,
+		Reason:   reason,
+	})
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	logger.Infof("Successfully revoked certificate %s", cert.Id)
+	return mutationResponse(stub, nil, []string{cert.Id}, cert)
+}
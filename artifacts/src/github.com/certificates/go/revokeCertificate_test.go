@@ -0,0 +1,38 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/certificates/go/ccerrors"
+	"github.com/certificates/go/cctest"
+	"github.com/certificates/go/events"
+)
+
+func TestRevokeCertificate(t *testing.T) {
+	f := newFixture(t)
+
+	var mutation MutationResult
+	var verdict VerificationVerdict
+	f.stub.Run(t, []cctest.Case{
+		{Name: "arguments", Identity: f.issuer1, Function: "revokeCertificate", Args: []string{f.certID}, Code: ccerrors.InvalidArgument},
+		{Name: "no reason", Identity: f.issuer1, Function: "revokeCertificate", Args: []string{f.certID, ""}, Code: ccerrors.InvalidArgument},
+		{Name: "not found", Identity: f.issuer1, Function: "revokeCertificate", Args: []string{"cert:nope", "Fraud"}, Code: ccerrors.NotFound},
+		{Name: "another issuer", Identity: f.issuer2, Function: "revokeCertificate", Args: []string{f.certID, "Fraud"}, Code: ccerrors.Forbidden},
+		{Name: "revoked", Identity: f.issuer1, Function: "revokeCertificate", Args: []string{f.certID, "Fraud"},
+			Check: cctest.Decode(&mutation, func() error {
+				var revoked events.CertificateRevokedPayload
+				if err := expectEvent(f.stub, events.CertificateRevoked, &revoked); err != nil {
+					return err
+				}
+				return expect(len(mutation.Updated) == 1 && mutation.Updated[0] == f.certID &&
+					revoked.CertID == f.certID && revoked.Reason == "Fraud", "certificate revoked", revoked)
+			})},
+		{Name: "already revoked", Identity: f.issuer1, Function: "revokeCertificate", Args: []string{f.certID, "Fraud"}, Code: ccerrors.Conflict},
+		{Name: "verdict", Identity: f.issuer2, Function: "verifyCertificate", Args: []string{f.certID},
+			Check: cctest.Decode(&verdict, func() error {
+				return expect(!verdict.Valid && verdict.Revoked && verdict.RevocationReason == "Fraud" &&
+					verdict.Integrity.Valid && len(verdict.Failures) == 1 && verdict.Failures[0].Code == VERIFY_REVOKED,
+					"revoked verdict with a valid hash", verdict)
+			})},
+	})
+}
@@ -0,0 +1,312 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/certificates/go/ccerrors"
+	"github.com/certificates/go/validation"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	pb "github.com/hyperledger/fabric/protos/peer"
+)
+
+// Badge catalogue inverted index. Every searchable term of a badge is
+// stored as a composite key badge-term~field~term~badgeID (empty value).
+const BADGE_TERM_INDEX = "badge-term"
+
+// indexed fields
+const (
+	TERM_TEXT     = "text" // name and description tokens
+	TERM_TAG      = "tag"
+	TERM_CATEGORY = "category"
+	TERM_LEVEL    = "level"
+	TERM_LANGUAGE = "language"
+)
+
+// searchBadges reads the badges of a term by pages, never a whole posting list
+const (
+	SEARCH_PROBE_SIZE         = 100  // badges counted to find the rarest term
+	MAX_SEARCH_SCAN           = 1000 // badges of the rarest term read by page
+	SEARCH_BOOKMARK_SEPARATOR = "|"
+)
+
+// Search criteria accepted by searchBadges. All provided criteria must match.
+type BadgeSearch struct {
+	Text     string   `json:"text"`
+	Tags     []string `json:"tags"`
+	Category string   `json:"category"`
+	Level    string   `json:"level"`
+	Language string   `json:"language"`
+}
+
+// Search the badge catalogue, paginated.
+//
+// Arguments: 1) BadgeSearch (JSON), 2) pageSize, 3) bookmark (returned by
+// the previous page)
+//
+// A page reads at most MAX_SEARCH_SCAN badges of the rarest term, so it can
+// have fewer than pageSize badges while the bookmark isn't empty.
+func (t *SimpleChaincode) searchBadges(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+
+	if len(args) != 3 {
+		return errorResponse(ccerrors.New(ccerrors.InvalidArgument, "Incorrect number of arguments. Expecting 3: 1) search (JSON), 2) pageSize, 3) bookmark"))
+	}
+
+	var search BadgeSearch
+	decoder := json.NewDecoder(bytes.NewReader([]byte(args[0])))
+	decoder.DisallowUnknownFields()
+	err := decoder.Decode(&search)
+	if err != nil {
+		return errorResponse(ccerrors.New(ccerrors.InvalidArgument, "Invalid search: "+err.Error()))
+	}
+
+	v := validation.New()
+	v.OptionalName("text", search.Text)
+	for _, tag := range search.Tags {
+		v.OptionalName("tags", tag)
+	}
+	v.OptionalName("category", search.Category)
+	v.OptionalName("level", search.Level)
+	v.OptionalName("language", search.Language)
+	if err := v.Err(); err != nil {
+		return errorResponse(err)
+	}
+
+	pageSize, bookmark, err := parsePaginationArgs(args[1], args[2])
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	// 1. Build the list of (field, term) that must match
+	// --------------------------------------------------
+	var terms [][]string
+	for _, token := range tokenize(search.Text) {
+		terms = append(terms, []string{TERM_TEXT, token})
+	}
+	for _, tag := range search.Tags {
+		terms = append(terms, []string{TERM_TAG, normalizeTerm(tag)})
+	}
+	if search.Category != "" {
+		terms = append(terms, []string{TERM_CATEGORY, normalizeTerm(search.Category)})
+	}
+	if search.Level != "" {
+		terms = append(terms, []string{TERM_LEVEL, normalizeTerm(search.Level)})
+	}
+	if search.Language != "" {
+		terms = append(terms, []string{TERM_LANGUAGE, normalizeTerm(search.Language)})
+	}
+
+	if len(terms) == 0 {
+		return errorResponse(ccerrors.New(ccerrors.InvalidArgument, "Search must include text, tags, category, level or language"))
+	}
+
+	// 2. Page the rarest term, the driver, and check the other terms of
+	//    each of its badges with point reads. The driver is chosen on the
+	//    first page and kept in the bookmark (driver|index bookmark)
+	// ----------------------------------------------------------------
+	driver, termBookmark, err := parseSearchBookmark(bookmark, len(terms))
+	if err != nil {
+		return errorResponse(err)
+	}
+	if bookmark == "" {
+		driver, err = getRarestTerm(stub, terms)
+		if err != nil {
+			return errorResponse(err)
+		}
+	}
+
+	page := PagedResponse{Records: []QueryRecord{}}
+	scanned := 0
+	for int32(len(page.Records)) < pageSize && scanned < MAX_SEARCH_SCAN {
+		fetchSize := pageSize - int32(len(page.Records))
+		resultsIterator, metadata, err := stub.GetStateByPartialCompositeKeyWithPagination(BADGE_TERM_INDEX, terms[driver], fetchSize, termBookmark)
+		if err != nil {
+			return errorResponse(errors.New("Failed to search " + terms[driver][0] + " " + terms[driver][1] + ": " + err.Error()))
+		}
+
+		var badgeIDs []string
+		for resultsIterator.HasNext() {
+			indexKV, err := resultsIterator.Next()
+			if err != nil {
+				resultsIterator.Close()
+				return errorResponse(err)
+			}
+			_, keyParts, err := stub.SplitCompositeKey(indexKV.Key)
+			if err != nil {
+				resultsIterator.Close()
+				return errorResponse(err)
+			}
+			badgeIDs = append(badgeIDs, keyParts[2])
+		}
+		resultsIterator.Close()
+		scanned += len(badgeIDs)
+
+		// 3. Read the matching badges
+		// ---------------------------
+		for _, badgeID := range badgeIDs {
+			matches, err := badgeMatchesTerms(stub, badgeID, terms, driver)
+			if err != nil {
+				return errorResponse(err)
+			}
+			if !matches {
+				continue
+			}
+
+			badgeBytes, err := stub.GetState(badgeID)
+			if err != nil {
+				return errorResponse(ccerrors.New(ccerrors.Internal, "Failed to get state for "+badgeID))
+			}
+			if badgeBytes == nil {
+				logger.Warningf("Indexed badge %s doesn't exist, skipping...", badgeID)
+				continue
+			}
+			page.Records = append(page.Records, QueryRecord{Key: badgeID, Record: badgeBytes})
+		}
+
+		termBookmark = metadata.Bookmark
+		if termBookmark == "" || int32(len(badgeIDs)) < fetchSize {
+			termBookmark = ""
+			break
+		}
+	}
+
+	page.FetchedCount = int32(len(page.Records))
+	if termBookmark != "" {
+		page.Bookmark = strconv.Itoa(driver) + SEARCH_BOOKMARK_SEPARATOR + termBookmark
+	}
+
+	return marshalPage(page)
+}
+
+// Function that returns the index (in terms) of the term with the fewest
+// badges, counting at most SEARCH_PROBE_SIZE badges per term
+func getRarestTerm(stub shim.ChaincodeStubInterface, terms [][]string) (int, error) {
+	rarest, rarestCount := 0, int32(-1)
+	for i, term := range terms {
+		resultsIterator, metadata, err := stub.GetStateByPartialCompositeKeyWithPagination(BADGE_TERM_INDEX, term, SEARCH_PROBE_SIZE, "")
+		if err != nil {
+			return 0, errors.New("Failed to search " + term[0] + " " + term[1] + ": " + err.Error())
+		}
+		resultsIterator.Close()
+
+		if rarestCount == -1 || metadata.FetchedRecordsCount < rarestCount {
+			rarest, rarestCount = i, metadata.FetchedRecordsCount
+		}
+	}
+	return rarest, nil
+}
+
+// Function that checks that a badge is indexed for every term but the
+// driver (already matched)
+func badgeMatchesTerms(stub shim.ChaincodeStubInterface, badgeID string, terms [][]string, driver int) (bool, error) {
+	for i, term := range terms {
+		if i == driver {
+			continue
+		}
+		indexKey, err := stub.CreateCompositeKey(BADGE_TERM_INDEX, []string{term[0], term[1], badgeID})
+		if err != nil {
+			return false, ccerrors.New(ccerrors.InvalidArgument, "Error creating index key for "+badgeID+": "+err.Error())
+		}
+		indexed, err := stateExists(stub, indexKey)
+		if err != nil || !indexed {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// Function that splits a searchBadges bookmark (driver|index bookmark)
+func parseSearchBookmark(bookmark string, termCount int) (int, string, error) {
+	if bookmark == "" {
+		return 0, "", nil
+	}
+
+	parts := strings.SplitN(bookmark, SEARCH_BOOKMARK_SEPARATOR, 2)
+	if len(parts) == 2 {
+		driver, err := strconv.Atoi(parts[0])
+		if err == nil && driver >= 0 && driver < termCount && parts[1] != "" {
+			return driver, parts[1], nil
+		}
+	}
+	return 0, "", ccerrors.New(ccerrors.InvalidArgument, "Invalid bookmark, it must be the one returned by the previous page of the same search")
+}
+
+// Function that writes the inverted index entries of a badge
+func indexBadgeTerms(stub shim.ChaincodeStubInterface, badge Badge) error {
+	terms := map[string][]string{
+		TERM_TEXT: tokenize(badge.Name + " " + badge.Description),
+	}
+	for _, tag := range badge.Tags {
+		terms[TERM_TAG] = append(terms[TERM_TAG], normalizeTerm(tag))
+	}
+	if badge.Category != "" {
+		terms[TERM_CATEGORY] = []string{normalizeTerm(badge.Category)}
+	}
+	if badge.Level != "" {
+		terms[TERM_LEVEL] = []string{normalizeTerm(badge.Level)}
+	}
+	if badge.Language != "" {
+		terms[TERM_LANGUAGE] = []string{normalizeTerm(badge.Language)}
+	}
+
+	for field, fieldTerms := range terms {
+		for _, term := range fieldTerms {
+			if term == "" {
+				continue
+			}
+
+			indexKey, err := stub.CreateCompositeKey(BADGE_TERM_INDEX, []string{field, term, badge.Id})
+			if err != nil {
+				return ccerrors.New(ccerrors.InvalidArgument, "Error creating index key for "+badge.Id+": "+err.Error())
+			}
+
+			// value can't be empty (that would delete the key)
+			err = stub.PutState(indexKey, []byte{0x00})
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// Function that splits free text in folded (see foldText), deduplicated
+// tokens (letters and digits, at least 2 chars)
+func tokenize(text string) []string {
+	var tokens []string
+	seen := make(map[string]bool)
+
+	fields := strings.FieldsFunc(foldText(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for _, field := range fields {
+		if len([]rune(field)) < 2 || seen[field] {
+			continue
+		}
+		seen[field] = true
+		tokens = append(tokens, field)
+	}
+
+	return tokens
+}
+
+func normalizeTerm(term string) string {
+	return foldText(strings.TrimSpace(term))
+}
+
+// Function that splits a comma separated list of tags
+func splitTags(tags string) []string {
+	var result []string
+	for _, tag := range strings.Split(tags, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag != "" {
+			result = append(result, tag)
+		}
+	}
+	return result
+}
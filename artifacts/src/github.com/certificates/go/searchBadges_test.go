@@ -0,0 +1,70 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/certificates/go/ccerrors"
+	"github.com/certificates/go/cctest"
+)
+
+func TestSearchBadges(t *testing.T) {
+	f := newFixture(t)
+
+	var page PagedResponse
+	f.stub.Run(t, []cctest.Case{
+		{Name: "empty search", Identity: f.issuer1, Function: "searchBadges", Args: []string{`{}`, "10", ""}, Code: ccerrors.InvalidArgument},
+		{Name: "text and tags", Identity: f.issuer1, Function: "searchBadges", Args: []string{`{"text":"data","tags":["python"]}`, "10", ""},
+			Check: cctest.Decode(&page, func() error {
+				return expect(len(page.Records) == 2, "2 badges", page.Records)
+			})},
+		{Name: "no match", Identity: f.issuer1, Function: "searchBadges", Args: []string{`{"text":"history"}`, "10", ""},
+			Check: cctest.Decode(&page, func() error {
+				return expect(len(page.Records) == 0, "no badge", page.Records)
+			})},
+	})
+}
+
+func TestSearchBadgesPages(t *testing.T) {
+	f := newFixture(t)
+	f.invoke(t, f.issuer1, nil, "issueBadge", "University", "https://org1.example.com", "Ciência de Dados", "Badge description",
+		"Badge criteria", "Dean", "Signature", "dados", "ciência", "advanced", "pt", "")
+
+	var page PagedResponse
+	f.stub.Run(t, []cctest.Case{
+		{Name: "folded text", Identity: f.issuer1, Function: "searchBadges", Args: []string{`{"text":"CIENCIA"}`, "10", ""},
+			Check: cctest.Decode(&page, func() error {
+				return expect(len(page.Records) == 1 && page.Records[0].Key == makeBadgeID(testIssuer1, "ciencia-de-dados"), "1 badge", page)
+			})},
+		{Name: "folded category", Identity: f.issuer1, Function: "searchBadges", Args: []string{`{"category":"Ciencia"}`, "10", ""},
+			Check: cctest.Decode(&page, func() error {
+				return expect(len(page.Records) == 1, "1 badge", page)
+			})},
+		// python (2 badges) is rarer than badge (3 badges)
+		{Name: "rarest term", Identity: f.issuer1, Function: "searchBadges", Args: []string{`{"text":"badge","tags":["python"]}`, "1", ""},
+			Check: cctest.Decode(&page, func() error {
+				return expect(len(page.Records) == 1 && strings.HasPrefix(page.Bookmark, "1"+SEARCH_BOOKMARK_SEPARATOR), "a page of the python badges", page)
+			})},
+		{Name: "invalid bookmark", Identity: f.issuer1, Function: "searchBadges", Args: []string{`{"text":"badge"}`, "1", "5|x"},
+			Code: ccerrors.InvalidArgument},
+	})
+
+	// every page of a search, following the bookmarks
+	seen := make(map[string]bool)
+	bookmark := ""
+	for i := 0; i < 4; i++ {
+		payload := f.invoke(t, f.issuer1, nil, "searchBadges", `{"text":"badge"}`, "1", bookmark)
+		fatalOnError(t, json.Unmarshal(payload, &page))
+		for _, record := range page.Records {
+			seen[record.Key] = true
+		}
+		bookmark = page.Bookmark
+		if bookmark == "" {
+			break
+		}
+	}
+	if len(seen) != 3 || bookmark != "" {
+		t.Errorf("expected 3 badges in pages of 1, got %v (bookmark %q)", seen, bookmark)
+	}
+}
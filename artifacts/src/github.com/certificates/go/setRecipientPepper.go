@@ -0,0 +1,75 @@
+package main
+
+import (
+	"github.com/certificates/go/ccerrors"
+	"github.com/certificates/go/events"
+	"github.com/certificates/go/validation"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	pb "github.com/hyperledger/fabric/protos/peer"
+)
+
+// Sets the secret pepper of the caller's org collection, used to key the
+// recipient indexes (see getRecipientIndex). Admins only.
+//
+// The pepper is read from the transient map ("pepper" key), so it is not
+// written to the transaction. It must be random and is set once: the index
+// keys depend on it, changing it would orphan the existing indexes.
+func (t *SimpleChaincode) setRecipientPepper(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+
+	logger.Infof("Action: set Recipient pepper")
+
+	if len(args) != 0 {
+		return errorResponse(ccerrors.New(ccerrors.InvalidArgument, "Incorrect number of arguments. Expecting 0, pepper must be provided in the transient map"))
+	}
+
+	err := checkUserAttr(stub, "admin", "true")
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	transientMap, err := stub.GetTransient()
+	if err != nil {
+		return errorResponse(ccerrors.New(ccerrors.Internal, "Error getting transient map: "+err.Error()))
+	}
+
+	pepper := RecipientPepper{Pepper: string(transientMap[RECIPIENT_PEPPER_TRANSIENT_KEY])}
+
+	v := validation.New()
+	v.Secret(RECIPIENT_PEPPER_TRANSIENT_KEY, pepper.Pepper)
+	if err := v.Err(); err != nil {
+		return errorResponse(err)
+	}
+
+	collection, err := getRecipientCollection(stub)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	// 1. Check that the pepper isn't set yet
+	// --------------------------------------
+	var current RecipientPepper
+	err = getPrivateState(stub, collection, RECIPIENT_PEPPER_KEY, &current)
+	if err == nil {
+		return errorResponse(ccerrors.New(ccerrors.AlreadyExists, "Recipient pepper of "+collection+" is already set"))
+	}
+	if !isNotFound(err) {
+		return errorResponse(err)
+	}
+
+	// 2. Write the pepper into the org collection
+	// -------------------------------------------
+	err = marshalAndPutPrivateData(stub, collection, pepper, RECIPIENT_PEPPER_KEY)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	// 3. Emit the event, without the pepper
+	// -------------------------------------
+	err = emitEvent(stub, events.RecipientPepperSet, events.RecipientPepperSetPayload{Collection: collection})
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	logger.Infof("Successfully set recipient pepper of %s", collection)
+	return mutationResponse(stub, nil, nil, nil)
+}
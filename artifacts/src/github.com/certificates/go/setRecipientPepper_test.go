@@ -0,0 +1,60 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/certificates/go/ccerrors"
+	"github.com/certificates/go/cctest"
+	"github.com/certificates/go/events"
+)
+
+func TestSetRecipientPepper(t *testing.T) {
+	f := newLedger(t)
+	f.invoke(t, f.issuer2, nil, "issueBadge", badgeArgs("https://org2.example.com", "Data Science", "data-science")...)
+
+	org2, err := cctest.NewCA("Org2MSP")
+	fatalOnError(t, err)
+	admin2, err := org2.NewIdentity(testIssuer2, map[string]string{"role": "university", "email": testIssuer2, "admin": "true"})
+	fatalOnError(t, err)
+
+	pepper := map[string][]byte{RECIPIENT_PEPPER_TRANSIENT_KEY: []byte(testPepper)}
+	f.stub.Run(t, []cctest.Case{
+		{Name: "arguments", Identity: f.admin, Transient: pepper, Function: "setRecipientPepper", Args: []string{testPepper}, Code: ccerrors.InvalidArgument},
+		{Name: "not admin", Identity: f.issuer1, Transient: pepper, Function: "setRecipientPepper", Code: ccerrors.Forbidden},
+		{Name: "no pepper", Identity: f.admin, Function: "setRecipientPepper", Code: ccerrors.InvalidArgument},
+		{Name: "short pepper", Identity: f.admin, Transient: map[string][]byte{RECIPIENT_PEPPER_TRANSIENT_KEY: []byte("pepper")},
+			Function: "setRecipientPepper", Code: ccerrors.InvalidArgument},
+		{Name: "already set", Identity: f.admin, Transient: pepper, Function: "setRecipientPepper", Code: ccerrors.AlreadyExists},
+		// Org2 has no pepper
+		{Name: "pepper not set", Identity: f.issuer2, Transient: f.recipient, Function: "issueCertificate",
+			Args: certArgs("data-science"), Code: ccerrors.NotFound},
+		{Name: "set", Identity: admin2, Transient: pepper, Function: "setRecipientPepper",
+			Check: func(payload []byte) error {
+				var set events.RecipientPepperSetPayload
+				if err := expectEvent(f.stub, events.RecipientPepperSet, &set); err != nil {
+					return err
+				}
+				return expect(set.Collection == RECIPIENT_COLLECTION_PREFIX+"Org2MSP" && !strings.Contains(string(f.stub.Event.Payload), testPepper),
+					"event of the Org2 collection without the pepper", string(f.stub.Event.Payload))
+			}},
+		{Name: "issued with the pepper", Identity: f.issuer2, Transient: f.recipient, Function: "issueCertificate", Args: certArgs("data-science")},
+	})
+}
+
+// The private data keys are published as hashes, the recipient email must
+// not appear in them
+func TestRecipientIndexKey(t *testing.T) {
+	f := newFixture(t)
+
+	for collection, state := range f.stub.PvtState {
+		for key := range state {
+			if strings.Contains(key, testRecipient) {
+				t.Errorf("expected no recipient email in the keys of %s, got %s", collection, key)
+			}
+		}
+	}
+	if _, ok := f.stub.PvtState[RECIPIENT_COLLECTION_PREFIX+"Org1MSP"][recipientIndexKey(testPepper, testRecipient)]; !ok {
+		t.Errorf("expected the recipient index keyed with the pepper, got %v", f.stub.PvtState)
+	}
+}
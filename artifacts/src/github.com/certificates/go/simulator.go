@@ -0,0 +1,388 @@
+//go:build simulator
+// +build simulator
+
+// Offline simulator: runs the chaincode in-process on a mock stub (see
+// package cctest), with the world state kept in a local JSON file between
+// runs. No peer, orderer or docker-compose needed.
+//
+// Build it with: go build -tags simulator -o ccsim .
+//
+//	ccsim -email issuer1@org1.example.com invoke initLedger
+//	ccsim -email issuer1@org1.example.com invoke issueBadge "Org1 University" \
+//		https://org1.example.com "Go 101" "Go basics" "Pass the exam" Dean "J. Doe"
+//	ccsim -role student -email student@example.com query getCertificate <certID>
+//	ccsim state badge:
+//	ccsim reset
+//
+// Each call prints the response, the event and the read/write set. invoke
+// saves the new state, query throws it away. The identity is a synthetic
+// certificate with the role and email attributes (or -cert, e.g. minted by
+// cmd/ccidentity).
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/certificates/go/cctest"
+	"github.com/certificates/go/internal/kvflag"
+	pb "github.com/hyperledger/fabric/protos/peer"
+)
+
+// simulatorState is the state file content. Values are stored as strings,
+// the chaincode only writes JSON.
+type simulatorState struct {
+	TxCount int                          `json:"txCount"`
+	State   map[string]string            `json:"state"`
+	Private map[string]map[string]string `json:"private,omitempty"`
+}
+
+// simulatorOutput is the -json output of invoke and query
+type simulatorOutput struct {
+	TxID    string             `json:"txId"`
+	Status  int32              `json:"status"`
+	Message string             `json:"message,omitempty"`
+	Payload interface{}        `json:"payload,omitempty"`
+	Event   *simulatorEvent    `json:"event,omitempty"`
+	Reads   []cctest.Read      `json:"reads"`
+	Ranges  []cctest.RangeRead `json:"rangeReads"`
+	Writes  []simulatorWrite   `json:"writes"`
+}
+
+type simulatorEvent struct {
+	Name    string      `json:"name"`
+	Payload interface{} `json:"payload,omitempty"`
+}
+
+type simulatorWrite struct {
+	Collection string      `json:"collection,omitempty"`
+	Key        string      `json:"key"`
+	Value      interface{} `json:"value,omitempty"`
+	Delete     bool        `json:"delete,omitempty"`
+}
+
+func main() {
+	attrs := kvflag.KeyValues{}
+	transient := kvflag.KeyValues{}
+	stateFile := flag.String("state", "simulator-state.json", "world state file")
+	mspID := flag.String("msp", "Org1MSP", "MSP ID of the identity")
+	role := flag.String("role", "university", "role attribute of the identity")
+	email := flag.String("email", "", "email attribute of the identity")
+	certFile := flag.String("cert", "", "PEM certificate of the identity (instead of -role, -email and -attr)")
+	asJSON := flag.Bool("json", false, "print invoke and query results as JSON")
+	flag.Var(attrs, "attr", "extra certificate attribute name=value (repeatable)")
+	flag.Var(transient, "transient", "transient map entry key=value (repeatable), e.g. recipient={...}")
+	flag.Usage = simulatorUsage
+	flag.Parse()
+
+	args := flag.Args()
+	if len(args) == 0 {
+		simulatorUsage()
+		os.Exit(2)
+	}
+
+	switch args[0] {
+	case "invoke", "query":
+		if len(args) < 2 {
+			simulatorUsage()
+			os.Exit(2)
+		}
+		if *role != "" {
+			attrs["role"] = *role
+		}
+		if *email != "" {
+			attrs["email"] = *email
+		}
+		identity, err := simulatorIdentity(*mspID, *certFile, attrs)
+		exitOnError(err)
+
+		stub := cctest.NewStub("certificates", new(SimpleChaincode))
+		exitOnError(loadSimulatorState(stub, *stateFile))
+		stub.Clock = time.Now().UTC()
+
+		var transientMap map[string][]byte
+		if len(transient) > 0 {
+			transientMap = make(map[string][]byte)
+			for key, value := range transient {
+				transientMap[key] = []byte(value)
+			}
+		}
+
+		resp := stub.As(identity).InvokeWithTransient(transientMap, args[1], args[2:]...)
+		if *asJSON {
+			printJSON(simulatorResult(stub, resp))
+		} else {
+			printResult(stub, resp)
+		}
+
+		if args[0] == "invoke" && resp.Status < 400 {
+			exitOnError(saveSimulatorState(stub, *stateFile))
+		}
+		if resp.Status >= 400 {
+			os.Exit(1)
+		}
+
+	case "state":
+		prefix := ""
+		if len(args) > 1 {
+			prefix = args[1]
+		}
+		stub := cctest.NewStub("certificates", new(SimpleChaincode))
+		exitOnError(loadSimulatorState(stub, *stateFile))
+		printState(stub, prefix)
+
+	case "reset":
+		err := os.Remove(*stateFile)
+		if err != nil && !os.IsNotExist(err) {
+			exitOnError(err)
+		}
+
+	default:
+		simulatorUsage()
+		os.Exit(2)
+	}
+}
+
+func simulatorUsage() {
+	fmt.Fprintf(os.Stderr, `Usage: %s [flags] command
+
+Commands:
+  invoke <function> [args...]  run a transaction and save the new state
+  query <function> [args...]   run a transaction without saving the state
+  state [prefix]               print the world state (keys starting with prefix)
+  reset                        delete the state file
+
+Flags:
+`, os.Args[0])
+	flag.PrintDefaults()
+}
+
+// simulatorIdentity loads certFile or mints a certificate with attrs from a
+// throwaway CA of mspID
+func simulatorIdentity(mspID, certFile string, attrs map[string]string) (*cctest.Identity, error) {
+	if certFile != "" {
+		certPEM, err := ioutil.ReadFile(certFile)
+		if err != nil {
+			return nil, err
+		}
+		return cctest.IdentityFromCert(mspID, certPEM)
+	}
+
+	ca, err := cctest.NewCA(mspID)
+	if err != nil {
+		return nil, err
+	}
+	commonName := attrs["email"]
+	if commonName == "" {
+		commonName = "user." + mspID
+	}
+	return ca.NewIdentity(commonName, attrs)
+}
+
+func loadSimulatorState(stub *cctest.Stub, stateFile string) error {
+	data, err := ioutil.ReadFile(stateFile)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var file simulatorState
+	err = json.Unmarshal(data, &file)
+	if err != nil {
+		return errors.New(stateFile + ": " + err.Error())
+	}
+
+	state := make(map[string][]byte, len(file.State))
+	for key, value := range file.State {
+		state[key] = []byte(value)
+	}
+	private := make(map[string]map[string][]byte, len(file.Private))
+	for collection, m := range file.Private {
+		private[collection] = make(map[string][]byte, len(m))
+		for key, value := range m {
+			private[collection][key] = []byte(value)
+		}
+	}
+
+	stub.Load(state, private)
+	stub.TxCount = file.TxCount
+	return nil
+}
+
+// saveSimulatorState writes the world state and private data. Key level
+// endorsement policies are not saved, they are only checked by peers.
+func saveSimulatorState(stub *cctest.Stub, stateFile string) error {
+	file := simulatorState{
+		TxCount: stub.TxCount,
+		State:   make(map[string]string, len(stub.State)),
+		Private: make(map[string]map[string]string, len(stub.PvtState)),
+	}
+	for key, value := range stub.State {
+		if !utf8.Valid(value) {
+			return errors.New("value of " + key + " is not UTF-8 and can't be saved")
+		}
+		file.State[key] = string(value)
+	}
+	for collection, m := range stub.PvtState {
+		file.Private[collection] = make(map[string]string, len(m))
+		for key, value := range m {
+			if !utf8.Valid(value) {
+				return errors.New("value of " + key + " in " + collection + " is not UTF-8 and can't be saved")
+			}
+			file.Private[collection][key] = string(value)
+		}
+	}
+
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(stateFile, append(data, '\n'), 0644)
+}
+
+func simulatorResult(stub *cctest.Stub, resp pb.Response) simulatorOutput {
+	out := simulatorOutput{
+		TxID:    stub.LastTxID,
+		Status:  resp.Status,
+		Message: resp.Message,
+		Payload: jsonValue(resp.Payload),
+		Reads:   stub.RWSet.Reads,
+		Ranges:  stub.RWSet.RangeReads,
+		Writes:  []simulatorWrite{},
+	}
+	if stub.Event != nil {
+		out.Event = &simulatorEvent{Name: stub.Event.EventName, Payload: jsonValue(stub.Event.Payload)}
+	}
+	for _, write := range stub.RWSet.Writes {
+		out.Writes = append(out.Writes, simulatorWrite{
+			Collection: write.Collection,
+			Key:        write.Key,
+			Value:      jsonValue(write.Value),
+			Delete:     write.Delete,
+		})
+	}
+	return out
+}
+
+func printResult(stub *cctest.Stub, resp pb.Response) {
+	fmt.Printf("Transaction %s: status %d\n", stub.LastTxID, resp.Status)
+	if resp.Message != "" {
+		fmt.Printf("Message: %s\n", resp.Message)
+	}
+	if len(resp.Payload) > 0 {
+		fmt.Println("Payload:")
+		printValue(resp.Payload, "  ")
+	}
+
+	if stub.Event != nil {
+		fmt.Printf("Event: %s\n", stub.Event.EventName)
+		printValue(stub.Event.Payload, "  ")
+	}
+
+	fmt.Println("Reads:")
+	for _, read := range stub.RWSet.Reads {
+		fmt.Printf("  %s\n", displayKey(read.Collection, read.Key))
+	}
+	for _, rangeRead := range stub.RWSet.RangeReads {
+		fmt.Printf("  [%s, %s)\n", displayKey(rangeRead.Collection, rangeRead.StartKey), displayKey("", rangeRead.EndKey))
+	}
+
+	fmt.Println("Writes:")
+	for _, write := range stub.RWSet.Writes {
+		if write.Delete {
+			fmt.Printf("  %s (deleted)\n", displayKey(write.Collection, write.Key))
+			continue
+		}
+		fmt.Printf("  %s =\n", displayKey(write.Collection, write.Key))
+		printValue(write.Value, "    ")
+	}
+}
+
+func printState(stub *cctest.Stub, prefix string) {
+	for elem := stub.Keys.Front(); elem != nil; elem = elem.Next() {
+		key := elem.Value.(string)
+		if strings.HasPrefix(key, prefix) {
+			fmt.Printf("%s =\n", displayKey("", key))
+			printValue(stub.State[key], "  ")
+		}
+	}
+
+	var collections []string
+	for collection := range stub.PvtState {
+		collections = append(collections, collection)
+	}
+	sort.Strings(collections)
+	for _, collection := range collections {
+		var keys []string
+		for key := range stub.PvtState[collection] {
+			if strings.HasPrefix(key, prefix) {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			fmt.Printf("%s =\n", displayKey(collection, key))
+			printValue(stub.PvtState[collection][key], "  ")
+		}
+	}
+}
+
+// displayKey quotes composite keys (which contain U+0000) and prefixes
+// private keys with their collection
+func displayKey(collection, key string) string {
+	if strings.ContainsRune(key, 0) || strings.ContainsRune(key, utf8.MaxRune) {
+		key = fmt.Sprintf("%+q", key)
+	}
+	if collection != "" {
+		return collection + "/" + key
+	}
+	return key
+}
+
+// printValue prints JSON values indented, anything else as a string
+func printValue(value []byte, indent string) {
+	var pretty []byte
+	v := jsonValue(value)
+	if raw, ok := v.(json.RawMessage); ok {
+		pretty, _ = json.MarshalIndent(raw, indent, "  ")
+	} else {
+		pretty = value
+	}
+	fmt.Printf("%s%s\n", indent, pretty)
+}
+
+func printJSON(v interface{}) {
+	data, err := json.MarshalIndent(v, "", "  ")
+	exitOnError(err)
+	fmt.Println(string(data))
+}
+
+// jsonValue returns value as raw JSON if it is valid JSON, as a string
+// otherwise, and nil if it is empty
+func jsonValue(value []byte) interface{} {
+	if len(value) == 0 {
+		return nil
+	}
+	if json.Valid(value) {
+		return json.RawMessage(value)
+	}
+	return string(value)
+}
+
+func exitOnError(err error) {
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "simulator:", err)
+		os.Exit(1)
+	}
+}
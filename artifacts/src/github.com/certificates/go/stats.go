@@ -0,0 +1,188 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/certificates/go/ccerrors"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	pb "github.com/hyperledger/fabric/protos/peer"
+)
+
+// Statistics are stored as delta keys, one per transaction and subject:
+// stat-delta~issuerID~badgeID~month~metric~txID~subject -> "+1"/"-1"
+//
+// Every transaction writes its own keys, so issuance never conflicts on a
+// shared counter. Deltas are aggregated at read time.
+//
+// month is the issuedOn month (UTC) of the certificate, for every metric:
+// the revoked and erased counts of a month are those of the certificates
+// issued that month, whenever they were revoked or erased.
+const STATS_DELTA_INDEX = "stat-delta"
+
+// page size of the deltas reads
+var STATS_PAGE_SIZE int32 = 1000
+
+// metrics
+const (
+	STAT_ISSUED  = "issued"
+	STAT_REVOKED = "revoked"
+	STAT_ERASED  = "erased"
+)
+
+type Stats struct {
+	Issuer  string                 `json:"issuer"`
+	Badge   string                 `json:"badge,omitempty"`
+	Total   int                    `json:"total"`
+	Revoked int                    `json:"revoked"`
+	Erased  int                    `json:"erased"`
+	Months  map[string]MonthlyStat `json:"months"`
+}
+
+type MonthlyStat struct {
+	Issued  int `json:"issued"`
+	Revoked int `json:"revoked"`
+	Erased  int `json:"erased"`
+}
+
+// Query issuer statistics. Argument: 1) Issuer ID (email)
+func (t *SimpleChaincode) getIssuerStats(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+
+	if len(args) != 1 {
+		return errorResponse(ccerrors.New(ccerrors.InvalidArgument, "Incorrect number of arguments. Expecting 1: Issuer ID"))
+	}
+
+	stats, err := aggregateStats(stub, []string{args[0]})
+	if err != nil {
+		return errorResponse(err)
+	}
+	stats.Issuer = args[0]
+
+	return marshalStats(stats)
+}
+
+// Query badge statistics.
+// Argument: 1) Badge ID (issuerID/slug)
+func (t *SimpleChaincode) getBadgeStats(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+
+	if len(args) != 1 {
+		return errorResponse(ccerrors.New(ccerrors.InvalidArgument, "Incorrect number of arguments. Expecting 1: Badge ID"))
+	}
+
+	badgeID := BADGE_PREFIX + strings.TrimPrefix(args[0], BADGE_PREFIX)
+
+	var badge Badge
+	err := getState(stub, badgeID, &badge)
+	if isNotFound(err) {
+		return errorResponse(ccerrors.New(ccerrors.NotFound, "Badge doesn't exist"))
+	}
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	stats, err := aggregateStats(stub, []string{badge.Issuer.Id, badge.Id})
+	if err != nil {
+		return errorResponse(err)
+	}
+	stats.Issuer, stats.Badge = badge.Issuer.Id, badge.Id
+
+	return marshalStats(stats)
+}
+
+// Function that records a statistics delta of a certificate in its
+// issuedOn month
+func recordStatDelta(stub shim.ChaincodeStubInterface, cert Certificate, metric string, delta int) error {
+	issuedOn, err := time.Parse(time.RFC3339, cert.IssuedOn)
+	if err != nil {
+		return ccerrors.New(ccerrors.DataCorrupted, "Invalid issuedOn date in "+cert.Id)
+	}
+	month := issuedOn.UTC().Format("2006-01")
+
+	deltaKey, err := stub.CreateCompositeKey(STATS_DELTA_INDEX,
+		[]string{cert.Badge.Issuer.Id, cert.Badge.Id, month, metric, stub.GetTxID(), cert.Id})
+	if err != nil {
+		return errors.New("Error creating stats key: " + err.Error())
+	}
+
+	return stub.PutState(deltaKey, []byte(strconv.Itoa(delta)))
+}
+
+// Function that sums the deltas under a partial key (issuer or issuer+badge).
+//
+// The deltas are read page by page: an unpaginated range is cut at the peer
+// totalQueryLimit without error, which would give partial totals.
+func aggregateStats(stub shim.ChaincodeStubInterface, partialKey []string) (Stats, error) {
+	stats := Stats{Months: make(map[string]MonthlyStat)}
+
+	bookmark := ""
+	for {
+		resultsIterator, metadata, err := stub.GetStateByPartialCompositeKeyWithPagination(STATS_DELTA_INDEX, partialKey, STATS_PAGE_SIZE, bookmark)
+		if err != nil {
+			return stats, errors.New("Failed to get stats: " + err.Error())
+		}
+
+		var fetched int32
+		for resultsIterator.HasNext() {
+			deltaKV, err := resultsIterator.Next()
+			if err != nil {
+				resultsIterator.Close()
+				return stats, err
+			}
+			err = addStatDelta(stub, &stats, deltaKV.Key, deltaKV.Value)
+			if err != nil {
+				resultsIterator.Close()
+				return stats, err
+			}
+			fetched++
+		}
+		resultsIterator.Close()
+
+		if metadata == nil || metadata.FetchedRecordsCount != fetched {
+			return stats, ccerrors.New(ccerrors.Internal, "Statistics range was truncated, totals would be partial")
+		}
+		if metadata.Bookmark == "" || fetched < STATS_PAGE_SIZE {
+			return stats, nil
+		}
+		bookmark = metadata.Bookmark
+	}
+}
+
+// Function that adds a delta key to the statistics
+func addStatDelta(stub shim.ChaincodeStubInterface, stats *Stats, key string, value []byte) error {
+	_, keyParts, err := stub.SplitCompositeKey(key)
+	if err != nil {
+		return err
+	}
+	month, metric := keyParts[2], keyParts[3]
+
+	delta, err := strconv.Atoi(string(value))
+	if err != nil {
+		return errors.New("Invalid stats delta in " + key)
+	}
+
+	monthly := stats.Months[month]
+	switch metric {
+	case STAT_ISSUED:
+		stats.Total += delta
+		monthly.Issued += delta
+	case STAT_REVOKED:
+		stats.Revoked += delta
+		monthly.Revoked += delta
+	case STAT_ERASED:
+		stats.Erased += delta
+		monthly.Erased += delta
+	}
+	stats.Months[month] = monthly
+	return nil
+}
+
+func marshalStats(stats Stats) pb.Response {
+	out, err := json.Marshal(stats)
+	if err != nil {
+		return errorResponse(err)
+	}
+	return shim.Success(out)
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/certificates/go/ccerrors"
+	"github.com/certificates/go/cctest"
+)
+
+func TestIssuerStats(t *testing.T) {
+	f := newFixture(t)
+
+	var stats Stats
+	f.stub.Run(t, []cctest.Case{
+		{Name: "arguments", Identity: f.issuer1, Function: "getIssuerStats", Code: ccerrors.InvalidArgument},
+		{Name: "issued", Identity: f.issuer1, Function: "getIssuerStats", Args: []string{testIssuer1},
+			Check: cctest.Decode(&stats, func() error {
+				return expect(stats.Total == 1 && stats.Months["2018-10"].Issued == 1, "1 issued in 2018-10", stats)
+			})},
+	})
+
+	// issued and revoked in 2018-10, issuedOn 2018-01 (UTC)
+	other := cctest.Transient(RECIPIENT_TRANSIENT_KEY, RecipientPrivateDetails{Email: "other@example.com", Salt: testSalt})
+	payload := f.invoke(t, f.issuer1, other, "issueCertificate", "2017-12-31T23:30:00-02:00", "https://org1.example.com/verify", "data-science")
+	var mutation MutationResult
+	fatalOnError(t, json.Unmarshal(payload, &mutation))
+	f.invoke(t, f.issuer1, nil, "revokeCertificate", mutation.Created[0], "Fraud")
+
+	f.stub.Run(t, []cctest.Case{
+		{Name: "issuedOn month", Identity: f.issuer1, Function: "getIssuerStats", Args: []string{testIssuer1},
+			Check: cctest.Decode(&stats, func() error {
+				return expect(stats.Total == 2 && stats.Revoked == 1 && stats.Months["2018-10"].Issued == 1 &&
+					stats.Months["2018-01"].Issued == 1 && stats.Months["2018-01"].Revoked == 1,
+					"1 issued in 2018-10, 1 issued and revoked in 2018-01", stats)
+			})},
+	})
+}
+
+func TestBadgeStats(t *testing.T) {
+	f := newFixture(t)
+
+	var stats Stats
+	f.stub.Run(t, []cctest.Case{
+		{Name: "not found", Identity: f.issuer1, Function: "getBadgeStats", Args: []string{"nope/nope"}, Code: ccerrors.NotFound},
+		{Name: "issued", Identity: f.issuer1, Function: "getBadgeStats", Args: []string{f.badge1},
+			Check: cctest.Decode(&stats, func() error {
+				return expect(stats.Total == 1, "1 issued", stats)
+			})},
+		{Name: "none issued", Identity: f.issuer1, Function: "getBadgeStats", Args: []string{f.badge2},
+			Check: cctest.Decode(&stats, func() error {
+				return expect(stats.Total == 0, "0 issued", stats)
+			})},
+		{Name: "erased", Identity: f.issuer1, Function: "getBadgeStats", Args: []string{f.badge1},
+			Setup: func(s *cctest.Stub) {
+				s.As(f.admin).InvokeWithTransient(f.recipient, "forgetRecipient")
+			},
+			Check: cctest.Decode(&stats, func() error {
+				return expect(stats.Total == 1 && stats.Months["2018-10"].Erased == 1, "1 issued, 1 erased", stats)
+			})},
+	})
+}
+
+// The deltas are read in pages
+func TestStatsPages(t *testing.T) {
+	defer func(pageSize int32) { STATS_PAGE_SIZE = pageSize }(STATS_PAGE_SIZE)
+	STATS_PAGE_SIZE = 2
+
+	f := newFixture(t)
+	for _, email := range []string{"a@example.com", "b@example.com", "c@example.com"} {
+		f.invoke(t, f.issuer1, cctest.Transient(RECIPIENT_TRANSIENT_KEY, RecipientPrivateDetails{Email: email, Salt: testSalt}),
+			"issueCertificate", certArgs("data-science")...)
+	}
+
+	var stats Stats
+	f.stub.Run(t, []cctest.Case{
+		{Name: "issued", Identity: f.issuer1, Function: "getIssuerStats", Args: []string{testIssuer1},
+			Check: cctest.Decode(&stats, func() error {
+				return expect(stats.Total == 4 && stats.Months["2018-10"].Issued == 4, "4 issued over 2 pages", stats)
+			})},
+	})
+}
@@ -21,15 +21,27 @@ const ISSUER_LIST = "issuer-list"
 const BADGE_PREFIX = "badge:"
 const CERT_PREFIX = "cert:"
 
+// private data constants
+const RECIPIENT_COLLECTION_PREFIX = "recipients"
+const RECIPIENT_TRANSIENT_KEY = "recipient"
+const RECIPIENT_INDEX_PREFIX = "recipient-certs:"
+const RECIPIENT_PEPPER_KEY = "recipient-pepper"
+const RECIPIENT_PEPPER_TRANSIENT_KEY = "pepper"
+
 // Issuer storage structures
 type IssuerList struct {
 	IssuerSummary []IssuerSummary `json:"issuers"`
 }
 
+// Summary of a registered issuer
 type IssuerSummary struct {
-	Email    string   `json:"issuerEmail"`
-	BagdeIDs []string `json:"badgeIDs"`
-	CertIDs  []string `json:"certIDs"`
+	Email string `json:"issuerEmail"`
+	// Deprecated: no longer updated, they made issuance cost grow with the
+	// ledger. Use listIssuerBadges and listIssuerCertificates (keys
+	// badge:<email>/ and cert:<email>/). Kept to decode existing ledgers.
+	BagdeIDs []string `json:"badgeIDs,omitempty"`
+	// Deprecated: see BagdeIDs
+	CertIDs []string `json:"certIDs,omitempty"`
 }
 
 // Receiver storage structures
@@ -44,20 +56,92 @@ type ReceiverSummary struct {
 
 // Certificate structures
 type Certificate struct {
-	Context          string           `json:"@context"`
-	Id               string           `json:"id"`
-	Type             string           `json:"type"`
-	IssuedOn         string           `json:"issuedOn"`
-	Recipient        Recipient        `json:"recipient"`
-	RecipientProfile RecipientProfile `json:"recipientProfile"`
-	Verification     Verification     `json:"verification"`
-	Badge            Badge            `json:"badge"`
+	Context          string            `json:"@context"`
+	Id               string            `json:"id"`
+	Type             string            `json:"type"`
+	IssuedOn         string            `json:"issuedOn"`
+	Recipient        Recipient         `json:"recipient"`
+	RecipientProfile *RecipientProfile `json:"recipientProfile,omitempty"`
+	Verification     Verification      `json:"verification"`
+	Badge            Badge             `json:"badge"`
+	Attachments      []Attachment      `json:"attachments,omitempty"`
+	Expires          string            `json:"expires,omitempty"`
+	Revoked          bool              `json:"revoked,omitempty"`
+	RevocationReason string            `json:"revocationReason,omitempty"`
+	SubjectErased    bool              `json:"subjectErased,omitempty"`
+	Hash             string            `json:"hash,omitempty"`
+}
+
+// Certificate as stored in the ledger: the badge is a reference to the
+// badge version it was issued with (see utils_badgeversion.go). Functions
+// return the Certificate, with the badge embedded.
+type StoredCertificate struct {
+	Context          string            `json:"@context"`
+	Id               string            `json:"id"`
+	Type             string            `json:"type"`
+	IssuedOn         string            `json:"issuedOn"`
+	Recipient        Recipient         `json:"recipient"`
+	RecipientProfile *RecipientProfile `json:"recipientProfile,omitempty"`
+	Verification     Verification      `json:"verification"`
+	Badge            BadgeRef          `json:"badge"`
+	Attachments      []Attachment      `json:"attachments,omitempty"`
+	Expires          string            `json:"expires,omitempty"`
+	Revoked          bool              `json:"revoked,omitempty"`
+	RevocationReason string            `json:"revocationReason,omitempty"`
+	SubjectErased    bool              `json:"subjectErased,omitempty"`
+	Hash             string            `json:"hash,omitempty"`
+}
+
+// Reference to a badge version. The issuer ID is kept for the rich query
+// indexes (badge.issuer.id).
+type BadgeRef struct {
+	Id     string    `json:"id"`
+	Issuer IssuerRef `json:"issuer"`
+	// sha256$<hex> of the canonical JSON of the badge (see computeBadgeHash)
+	Version string `json:"version"`
+}
+
+type IssuerRef struct {
+	Id string `json:"id"`
 }
 
 type Recipient struct {
 	Identity string `json:"identity"`
 	Type     string `json:"type"`
 	Hashed   bool   `json:"hashed"`
+	Salt     string `json:"salt,omitempty"`
+}
+
+// Recipient private data, stored in the issuing org collection
+// (KEY: certID). Only its salted hash is written to channel state.
+type RecipientPrivateDetails struct {
+	Email     string `json:"email"`
+	Name      string `json:"name"`
+	PublicKey string `json:"publicKey"`
+	Salt      string `json:"salt"`
+}
+
+// Certificates of a recipient, stored in the issuing org collection
+// (KEY: recipient-certs:<hex(HMAC-SHA256(pepper, email))>). Private keys
+// are published as hashes, so the email is keyed with the org secret
+// pepper to keep it from being guessed. It is read with a single point
+// read, private data range queries forbid writes in the same transaction.
+type RecipientIndex struct {
+	CertIDs map[string]string `json:"certIds"` // badgeID -> certID
+}
+
+// Secret of an org used to key its recipient indexes, stored in the org
+// collection (KEY: recipient-pepper). It is set once, see
+// setRecipientPepper.
+type RecipientPepper struct {
+	Pepper string `json:"pepper"`
+}
+
+// SHA-256 digest of a document attached to the certificate (e.g. the PDF
+// diploma), in the form sha256$<hex>
+type Attachment struct {
+	Digest string   `json:"digest"`
+	Type   []string `json:"type"`
 }
 
 type RecipientProfile struct {
@@ -80,6 +164,10 @@ type Badge struct {
 	Image          string           `json:"image"`
 	Description    string           `json:"description"`
 	SignatureLines []SignatureLines `json:"signatureLines"`
+	Tags           []string         `json:"tags,omitempty"`
+	Category       string           `json:"category,omitempty"`
+	Level          string           `json:"level,omitempty"`
+	Language       string           `json:"language,omitempty"`
 }
 
 type Issuer struct {
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/certificates/go/ccerrors"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Badge IDs are namespaced by issuer: badge:<issuerID>/<slug>
+//
+// The slug is either provided by the issuer or derived from the badge name
+// (Unicode NFKD, accents removed, lowercase, words joined with '-').
+const MAX_SLUG_LENGTH = 64
+
+var slugPattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+func makeBadgeID(issuerID, slug string) string {
+	return BADGE_PREFIX + issuerID + "/" + slug
+}
+
+// Function that returns the badge ID of an argument: either a badge ID
+// (with or without the badge: prefix) or a slug of the issuer's badge
+func resolveBadgeID(issuerID, arg string) string {
+	arg = strings.TrimPrefix(arg, BADGE_PREFIX)
+	if strings.Contains(arg, "/") {
+		return BADGE_PREFIX + arg
+	}
+	return makeBadgeID(issuerID, arg)
+}
+
+// Function that reads the badge of an argument (see resolveBadgeID) and
+// returns its ID.
+//
+// Badges issued before the IDs were namespaced keep their legacy key,
+// badge:<name> (lowercase, without spaces): certificates and indexes
+// reference it, and it is part of the certificate hashes. A bare name that
+// isn't in the issuer's namespace falls back to that key, the caller still
+// checks that the badge is owned by the issuer.
+func getBadgeState(stub shim.ChaincodeStubInterface, issuerID, arg string, badge *Badge) (string, error) {
+	badgeID := resolveBadgeID(issuerID, arg)
+	err := getState(stub, badgeID, badge)
+	name := strings.TrimPrefix(arg, BADGE_PREFIX)
+	if !isNotFound(err) || strings.Contains(name, "/") {
+		return badgeID, err
+	}
+
+	legacyID := BADGE_PREFIX + strings.ToLower(strings.Replace(name, " ", "", -1))
+	legacyErr := getState(stub, legacyID, badge)
+	if isNotFound(legacyErr) {
+		return badgeID, err
+	}
+	return legacyID, legacyErr
+}
+
+// Function that validates an explicit slug
+func validateSlug(slug string) error {
+	if len(slug) > MAX_SLUG_LENGTH {
+		return ccerrors.New(ccerrors.InvalidArgument, "Badge slug must be at most "+strconv.Itoa(MAX_SLUG_LENGTH)+" characters")
+	}
+	if !slugPattern.MatchString(slug) {
+		return ccerrors.New(ccerrors.InvalidArgument, "Badge slug '"+slug+"' must contain only a-z, 0-9 and single '-' between words")
+	}
+	return nil
+}
+
+// Function that folds text for comparisons: compatibility decomposition
+// (NFKD) without the combining marks (accents), in lowercase. Slugs and
+// search terms are folded, so "Ciência" matches "ciencia".
+func foldText(text string) string {
+	var folded []rune
+	for _, r := range norm.NFKD.String(text) {
+		if unicode.Is(unicode.Mn, r) {
+			// combining mark (accent) removed by decomposition
+			continue
+		}
+		folded = append(folded, unicode.ToLower(r))
+	}
+	return string(folded)
+}
+
+// Function that derives a slug from a badge name
+func slugify(name string) (string, error) {
+	var slug []rune
+	pendingDash := false
+
+	for _, r := range foldText(name) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			if pendingDash && len(slug) > 0 {
+				slug = append(slug, '-')
+			}
+			slug = append(slug, r)
+			pendingDash = false
+		} else {
+			pendingDash = true
+		}
+	}
+
+	if len(slug) == 0 {
+		return "", ccerrors.New(ccerrors.InvalidArgument, "Can't derive a slug from badge name '"+name+"', provide an explicit slug")
+	}
+
+	if len(slug) > MAX_SLUG_LENGTH {
+		slug = slug[:MAX_SLUG_LENGTH]
+	}
+	return strings.TrimRight(string(slug), "-"), nil
+}
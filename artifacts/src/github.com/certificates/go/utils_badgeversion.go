@@ -0,0 +1,171 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/certificates/go/canonicaljson"
+	"github.com/certificates/go/ccerrors"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+// Badge versions.
+//
+// Certificates don't embed their badge: they store a BadgeRef to the
+// version of the badge they were issued with. Each version is stored once,
+// under badge-version~<badgeID>~<version>, and is embedded back into the
+// certificate when it is read. The version is the hash of the badge, so a
+// version record can't be altered without the read failing: they have no
+// key-level endorsement policy.
+//
+// Certificates issued before badge references embed the badge, they are
+// read as is and rewritten by migrateCertificates.
+
+// Badge versions index: badge-version~badgeID~version (canonical badge)
+const BADGE_VERSION_INDEX = "badge-version"
+
+// Function that reads a certificate (stored or legacy form) from the
+// ledger, with its badge version embedded
+func getCertificateState(stub shim.ChaincodeStubInterface, key string, cert *Certificate) error {
+	stateBytes, err := stub.GetState(key)
+	if err != nil {
+		return ccerrors.New(ccerrors.Internal, "Failed to get state for "+key)
+	}
+	if stateBytes == nil {
+		return ccerrors.New(ccerrors.NotFound, key+" doesn't exist")
+	}
+
+	return decodeCertificate(stub, key, stateBytes, cert)
+}
+
+// Function that decodes a certificate read from the ledger and embeds its
+// badge version
+func decodeCertificate(stub shim.ChaincodeStubInterface, key string, stateBytes []byte, cert *Certificate) error {
+	if !isBadgeReference(stateBytes) {
+		return decodeState(key, stateBytes, cert)
+	}
+
+	var stored StoredCertificate
+	err := decodeState(key, stateBytes, &stored)
+	if err != nil {
+		return err
+	}
+
+	badge, err := getBadgeVersion(stub, stored.Badge)
+	if err != nil {
+		return err
+	}
+
+	*cert = Certificate{
+		Context:          stored.Context,
+		Id:               stored.Id,
+		Type:             stored.Type,
+		IssuedOn:         stored.IssuedOn,
+		Recipient:        stored.Recipient,
+		RecipientProfile: stored.RecipientProfile,
+		Verification:     stored.Verification,
+		Badge:            badge,
+		Attachments:      stored.Attachments,
+		Expires:          stored.Expires,
+		Revoked:          stored.Revoked,
+		RevocationReason: stored.RevocationReason,
+		SubjectErased:    stored.SubjectErased,
+		Hash:             stored.Hash,
+	}
+	return nil
+}
+
+// Function that writes a certificate in the stored form, and the version of
+// its badge if it is new
+func putCertificateState(stub shim.ChaincodeStubInterface, cert Certificate) error {
+	badgeRef, err := putBadgeVersion(stub, cert.Badge)
+	if err != nil {
+		return err
+	}
+
+	stored := StoredCertificate{
+		Context:          cert.Context,
+		Id:               cert.Id,
+		Type:             cert.Type,
+		IssuedOn:         cert.IssuedOn,
+		Recipient:        cert.Recipient,
+		RecipientProfile: cert.RecipientProfile,
+		Verification:     cert.Verification,
+		Badge:            badgeRef,
+		Attachments:      cert.Attachments,
+		Expires:          cert.Expires,
+		Revoked:          cert.Revoked,
+		RevocationReason: cert.RevocationReason,
+		SubjectErased:    cert.SubjectErased,
+		Hash:             cert.Hash,
+	}
+	return marshalAndPutState(stub, stored, cert.Id)
+}
+
+// Function that writes a badge version if it doesn't exist yet and returns
+// its reference
+func putBadgeVersion(stub shim.ChaincodeStubInterface, badge Badge) (BadgeRef, error) {
+	badgeRef := BadgeRef{Id: badge.Id, Issuer: IssuerRef{Id: badge.Issuer.Id}}
+
+	var err error
+	badgeRef.Version, err = computeBadgeHash(badge)
+	if err != nil {
+		return badgeRef, err
+	}
+
+	versionKey, err := stub.CreateCompositeKey(BADGE_VERSION_INDEX, []string{badgeRef.Id, badgeRef.Version})
+	if err != nil {
+		return badgeRef, ccerrors.New(ccerrors.InvalidArgument, "Error creating badge version key for "+badge.Id+": "+err.Error())
+	}
+
+	versionExists, err := stateExists(stub, versionKey)
+	if err != nil || versionExists {
+		return badgeRef, err
+	}
+
+	out, err := canonicaljson.Marshal(badge)
+	if err != nil {
+		return badgeRef, err
+	}
+	return badgeRef, stub.PutState(versionKey, out)
+}
+
+// Function that reads a badge version and checks its hash
+func getBadgeVersion(stub shim.ChaincodeStubInterface, badgeRef BadgeRef) (Badge, error) {
+	var badge Badge
+
+	versionKey, err := stub.CreateCompositeKey(BADGE_VERSION_INDEX, []string{badgeRef.Id, badgeRef.Version})
+	if err != nil {
+		return badge, corruptState(badgeRef.Id, err)
+	}
+
+	err = getState(stub, versionKey, &badge)
+	if isNotFound(err) {
+		return badge, corruptState(versionKey, err)
+	}
+	if err != nil {
+		return badge, err
+	}
+
+	version, err := computeBadgeHash(badge)
+	if err != nil {
+		return badge, err
+	}
+	if version != badgeRef.Version {
+		return badge, corruptState(versionKey, errors.New("badge hash is "+version))
+	}
+	return badge, nil
+}
+
+// Function that tells a stored certificate (badge reference, with a
+// version) from a legacy one (embedded badge)
+func isBadgeReference(stateBytes []byte) bool {
+	var probe struct {
+		Badge struct {
+			Version *string `json:"version"`
+		} `json:"badge"`
+	}
+	// invalid JSON is reported by the strict decoding
+	json.Unmarshal(stateBytes, &probe)
+	return probe.Badge.Version != nil
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/certificates/go/canonicaljson"
+	"github.com/certificates/go/ccerrors"
+	"github.com/certificates/go/cctest"
+)
+
+func TestBadgeVersion(t *testing.T) {
+	f := newFixture(t)
+
+	var cert Certificate
+	var stored []byte
+	f.stub.Run(t, []cctest.Case{
+		{Name: "new badge version", Identity: f.issuer1, Function: "getCertificate", Args: []string{f.certID},
+			Setup: func(s *cctest.Stub) {
+				var badge Badge
+				json.Unmarshal(s.State[f.badge1], &badge)
+				badge.Description = "New description"
+				s.State[f.badge1], _ = canonicaljson.Marshal(badge)
+				stored = s.State[f.certID]
+			},
+			Check: cctest.Decode(&cert, func() error {
+				return expect(cert.Badge.Description == "Badge description" && string(f.stub.State[f.certID]) == string(stored),
+					"certificate with the version it was issued with", cert.Badge)
+			})},
+		{Name: "altered badge version", Identity: f.issuer1, Function: "getCertificate", Args: []string{f.certID},
+			Setup: func(s *cctest.Stub) {
+				var stored StoredCertificate
+				json.Unmarshal(s.State[f.certID], &stored)
+				versionKey, _ := s.CreateCompositeKey(BADGE_VERSION_INDEX, []string{f.badge1, stored.Badge.Version})
+				var badge Badge
+				json.Unmarshal(s.State[versionKey], &badge)
+				badge.Description = "Altered description"
+				s.State[versionKey], _ = canonicaljson.Marshal(badge)
+			},
+			Code: ccerrors.DataCorrupted},
+	})
+}
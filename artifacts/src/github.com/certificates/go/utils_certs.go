@@ -9,7 +9,7 @@ func createIssuerSummary(issuer Issuer) IssuerSummary {
 }
 
 // Function that creates certificate
-func createCertificate(id, issuedOn string, rec Recipient, recProf RecipientProfile,
+func createCertificate(id, issuedOn string, rec Recipient, recProf *RecipientProfile,
 	ver Verification, badge Badge) Certificate {
 
 	logger.Infof("Creating certificate")
@@ -36,6 +36,16 @@ func createRecipient(identity string) Recipient {
 	return recipient
 }
 
+func createHashedRecipient(identity, salt string) Recipient {
+	recipient := Recipient{
+		Identity: hashRecipientIdentity(identity, salt),
+		Type:     "email",
+		Hashed:   true,
+		Salt:     salt,
+	}
+	return recipient
+}
+
 func createRecipientProfile(pubKey, name string) RecipientProfile {
 	recipientProfile := RecipientProfile{
 		PublicKey: pubKey,
@@ -53,6 +63,14 @@ func createVerification(location string) Verification {
 	return verification
 }
 
+func createAttachment(digest string) Attachment {
+	attachment := Attachment{
+		Digest: "sha256$" + digest,
+		Type:   []string{"Attachment", "Extension"},
+	}
+	return attachment
+}
+
 func createBadge(id, name, description string, issuer Issuer, criteria Criteria, signature SignatureLines) Badge {
 
 	badge := Badge{
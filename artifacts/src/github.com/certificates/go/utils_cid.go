@@ -18,6 +18,7 @@ import (
 	"errors"
 	"strings"
 
+	"github.com/certificates/go/ccerrors"
 	"github.com/hyperledger/fabric/core/chaincode/lib/cid"
 	"github.com/hyperledger/fabric/core/chaincode/shim"
 )
@@ -44,13 +45,13 @@ func checkUserAttr(stub shim.ChaincodeStubInterface, attr, desiredAttr string) e
 	if !ok {
 		// The client identity does not possess the attribute
 		errMessage = "User do not have " + attr + " attribute"
-		return errors.New(errMessage)
+		return ccerrors.New(ccerrors.Forbidden, errMessage)
 	}
 
 	if strings.Compare(val, desiredAttr) != 0 {
 		// user is not an university
 		errMessage = "User " + attr + " is not equal to " + desiredAttr
-		return errors.New(errMessage)
+		return ccerrors.New(ccerrors.Forbidden, errMessage)
 	}
 
 	// logger.Infof("val = %s, ok = %s, err = %s\n", val, ok, err)
@@ -78,14 +79,31 @@ func getUserAttr(stub shim.ChaincodeStubInterface, attr string) (string, error)
 	if !ok {
 		// The client identity does not possess the attribute
 		errMessage = "User does not have '" + attr + "' attribute"
-		return "", errors.New(errMessage)
+		return "", ccerrors.New(ccerrors.Forbidden, errMessage)
 	}
 
 	if len(val) <= 0 {
 		// attribute is empty
 		errMessage = "User '" + attr + "' attribute is empty"
-		return "", errors.New(errMessage)
+		return "", ccerrors.New(ccerrors.Forbidden, errMessage)
 	}
 
 	return val, nil
 }
+
+// Function that get user's MSP ID.
+// Arguments:
+// - stub (shim.ChaincodeStubInterface)
+//
+// Return (val, err)
+// - (mspID, nil) if OK
+// - ("", Error) if Error
+func getUserMSPID(stub shim.ChaincodeStubInterface) (string, error) {
+	mspID, err := cid.GetMSPID(stub)
+	if err != nil {
+		// There was an error trying to retrieve the MSP ID
+		return "", errors.New("Error trying to retrieve the user's MSP ID")
+	}
+
+	return mspID, nil
+}
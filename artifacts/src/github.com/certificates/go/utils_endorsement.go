@@ -0,0 +1,53 @@
+package main
+
+import (
+	"errors"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	"github.com/hyperledger/fabric/core/chaincode/shim/ext/statebased"
+)
+
+// Function that attaches a key-level endorsement policy to a key, requiring
+// the endorsement of a peer of the user's org (the issuer's org).
+//
+// Later updates to the key (revocation, versioning...) need that org's
+// signature, regardless of the chaincode endorsement policy.
+func setIssuerEndorsementPolicy(stub shim.ChaincodeStubInterface, key string) error {
+	mspID, err := getUserMSPID(stub)
+	if err != nil {
+		return err
+	}
+
+	endorsementPolicy, err := statebased.NewStateEP(nil)
+	if err != nil {
+		return errors.New("Error creating endorsement policy: " + err.Error())
+	}
+
+	err = endorsementPolicy.AddOrgs(statebased.RoleTypePeer, mspID)
+	if err != nil {
+		return errors.New("Error adding " + mspID + " to endorsement policy: " + err.Error())
+	}
+
+	policy, err := endorsementPolicy.Policy()
+	if err != nil {
+		return errors.New("Error marshaling endorsement policy: " + err.Error())
+	}
+
+	err = stub.SetStateValidationParameter(key, policy)
+	if err != nil {
+		return errors.New("Error setting endorsement policy for " + key + ": " + err.Error())
+	}
+
+	logger.Infof("Endorsement policy set for key %s: %s peer", key, mspID)
+
+	return nil
+}
+
+// Function that lists the orgs of a key-level endorsement policy
+func getPolicyOrgs(policy []byte) ([]string, error) {
+	endorsementPolicy, err := statebased.NewStateEP(policy)
+	if err != nil {
+		return nil, err
+	}
+	return endorsementPolicy.ListOrgs(), nil
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"errors"
+	"time"
+
+	"github.com/certificates/go/events"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+// Function that returns the transaction timestamp in RFC3339 (UTC), so it
+// is the same in every endorsing peer
+func getTxTimestamp(stub shim.ChaincodeStubInterface) (string, error) {
+	txTimestamp, err := stub.GetTxTimestamp()
+	if err != nil {
+		return "", errors.New("Error getting transaction timestamp: " + err.Error())
+	}
+
+	return time.Unix(txTimestamp.Seconds, int64(txTimestamp.Nanos)).UTC().Format(time.RFC3339), nil
+}
+
+// Function that emits a versioned chaincode event (see events package).
+// Only the last event set in a transaction is kept by Fabric.
+func emitEvent(stub shim.ChaincodeStubInterface, eventType events.Type, payload interface{}) error {
+	timestamp, err := getTxTimestamp(stub)
+	if err != nil {
+		return err
+	}
+
+	eventBytes, err := events.New(eventType, stub.GetTxID(), timestamp, payload)
+	if err != nil {
+		return errors.New("Error marshaling " + string(eventType) + " event: " + err.Error())
+	}
+
+	err = stub.SetEvent(string(eventType), eventBytes)
+	if err != nil {
+		return errors.New("Error setting " + string(eventType) + " event: " + err.Error())
+	}
+
+	return nil
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+
+	"github.com/certificates/go/canonicaljson"
+	"github.com/certificates/go/ccerrors"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	pb "github.com/hyperledger/fabric/protos/peer"
+)
+
+// maximum number of records returned by a single page
+const MAX_PAGE_SIZE = 200
+
+// Uniform response of every list and query function
+type PagedResponse struct {
+	Records      []QueryRecord `json:"records"`
+	FetchedCount int32         `json:"fetchedCount"`
+	Bookmark     string        `json:"bookmark"`
+}
+
+type QueryRecord struct {
+	Key    string          `json:"key"`
+	Record json.RawMessage `json:"record"`
+}
+
+// Function that validates pageSize and bookmark arguments.
+// An empty bookmark returns the first page.
+func parsePaginationArgs(pageSizeArg, bookmark string) (int32, string, error) {
+	pageSize, err := strconv.ParseInt(pageSizeArg, 10, 32)
+	if err != nil {
+		return 0, "", ccerrors.New(ccerrors.InvalidArgument, "pageSize must be a number")
+	}
+
+	if pageSize <= 0 || pageSize > MAX_PAGE_SIZE {
+		return 0, "", ccerrors.New(ccerrors.InvalidArgument, "pageSize must be between 1 and "+strconv.Itoa(MAX_PAGE_SIZE))
+	}
+
+	return int32(pageSize), bookmark, nil
+}
+
+// Function that collects a page of records from a paginated iterator
+func collectPage(resultsIterator shim.StateQueryIteratorInterface, metadata *pb.QueryResponseMetadata) (PagedResponse, error) {
+	defer resultsIterator.Close()
+
+	page := PagedResponse{Records: []QueryRecord{}}
+	for resultsIterator.HasNext() {
+		queryResult, err := resultsIterator.Next()
+		if err != nil {
+			return page, err
+		}
+		page.Records = append(page.Records, QueryRecord{Key: queryResult.Key, Record: queryResult.Value})
+	}
+
+	if metadata != nil {
+		page.FetchedCount = metadata.FetchedRecordsCount
+		page.Bookmark = metadata.Bookmark
+	}
+
+	return page, nil
+}
+
+// Function that runs a rich query and returns one page of records
+func getQueryResultPage(stub shim.ChaincodeStubInterface, queryString string, pageSize int32, bookmark string) (PagedResponse, error) {
+	logger.Infof("Rich query: %s (pageSize %d)", queryString, pageSize)
+
+	resultsIterator, metadata, err := stub.GetQueryResultWithPagination(queryString, pageSize, bookmark)
+	if err != nil {
+		return PagedResponse{}, errors.New("Failed to run query: " + err.Error())
+	}
+
+	return collectPage(resultsIterator, metadata)
+}
+
+// Function that returns one page of the keys starting with prefix
+func getPrefixRangePage(stub shim.ChaincodeStubInterface, prefix string, pageSize int32, bookmark string) (PagedResponse, error) {
+	resultsIterator, metadata, err := stub.GetStateByRangeWithPagination(prefix, prefixRangeEnd(prefix), pageSize, bookmark)
+	if err != nil {
+		return PagedResponse{}, errors.New("Failed to get state range for " + prefix + ": " + err.Error())
+	}
+
+	return collectPage(resultsIterator, metadata)
+}
+
+// Function that returns the end key (excluded) of the range of the keys
+// starting with prefix. Prefixes end with an ASCII char (e.g. ':'), so the
+// range ends before the next one (';').
+func prefixRangeEnd(prefix string) string {
+	return prefix[:len(prefix)-1] + string(prefix[len(prefix)-1]+1)
+}
+
+func marshalPage(page PagedResponse) pb.Response {
+	out, err := canonicaljson.Marshal(page)
+	if err != nil {
+		return errorResponse(err)
+	}
+	return shim.Success(out)
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+
+	"github.com/certificates/go/ccerrors"
+	"github.com/certificates/go/validation"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+// Function that returns the recipients collection owned by the user's org.
+// Each org has its own collection (recipients<MSPID>), see
+// collections_config.json
+func getRecipientCollection(stub shim.ChaincodeStubInterface) (string, error) {
+	mspID, err := getUserMSPID(stub)
+	if err != nil {
+		return "", err
+	}
+	return RECIPIENT_COLLECTION_PREFIX + mspID, nil
+}
+
+// Function that reads the recipient details from the transient map, so they
+// are never written to the transaction proposal or to channel state.
+//
+// Expected transient key "recipient" with the JSON:
+// {"email": "...", "name": "...", "publicKey": "...", "salt": "..."}
+// salt is only needed to issue certificates (see issueCertificate).
+func getRecipientFromTransient(stub shim.ChaincodeStubInterface) (RecipientPrivateDetails, error) {
+	var details RecipientPrivateDetails
+
+	transientMap, err := stub.GetTransient()
+	if err != nil {
+		return details, errors.New("Error getting transient map: " + err.Error())
+	}
+
+	recipientBytes, ok := transientMap[RECIPIENT_TRANSIENT_KEY]
+	if !ok || len(recipientBytes) == 0 {
+		return details, ccerrors.New(ccerrors.InvalidArgument, "'"+RECIPIENT_TRANSIENT_KEY+"' must be provided in the transient map")
+	}
+
+	err = json.Unmarshal(recipientBytes, &details)
+	if err != nil {
+		return details, ccerrors.New(ccerrors.InvalidArgument, "Failed to decode '"+RECIPIENT_TRANSIENT_KEY+"' transient data: "+err.Error())
+	}
+
+	v := validation.New()
+	v.Email("recipient.email", details.Email)
+	v.OptionalName("recipient.name", details.Name)
+	v.Key("recipient.publicKey", details.PublicKey)
+	v.Key("recipient.salt", details.Salt)
+	if err := v.Err(); err != nil {
+		return details, err
+	}
+
+	return details, nil
+}
+
+// Function that computes the Open Badges hashed identity of a recipient:
+// "sha256$" + hex(sha256(identity + salt))
+func hashRecipientIdentity(identity, salt string) string {
+	sum := sha256.Sum256([]byte(identity + salt))
+	return "sha256$" + hex.EncodeToString(sum[:])
+}
+
+// Function that retrieves recipient private details from a collection.
+//
+// Returns nil details (and nil error) when they don't exist or the peer
+// can't serve them, so callers fall back to the public certificate.
+func getRecipientPrivateDetails(stub shim.ChaincodeStubInterface, collection, certID string) (*RecipientPrivateDetails, error) {
+	var details RecipientPrivateDetails
+	err := getPrivateState(stub, collection, certID, &details)
+	if isNotFound(err) {
+		return nil, nil
+	}
+	if ccerrors.CodeOf(err) == ccerrors.Internal {
+		// peer isn't a member of the collection
+		logger.Warningf("Private data for %s not available in %s: %s", certID, collection, err)
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &details, nil
+}
+
+// Function that joins the public certificate with the recipient details
+// stored in the caller's org collection. Certificates issued by other orgs
+// are returned untouched (recipient stays hashed).
+func joinRecipientPrivateDetails(stub shim.ChaincodeStubInterface, cert *Certificate) error {
+	collection, err := getRecipientCollection(stub)
+	if err != nil {
+		return err
+	}
+
+	details, err := getRecipientPrivateDetails(stub, collection, cert.Id)
+	if err != nil {
+		return err
+	}
+	if details == nil {
+		// caller is not authorized to see recipient data
+		return nil
+	}
+
+	// check that private data matches the public hash
+	if hashRecipientIdentity(details.Email, details.Salt) != cert.Recipient.Identity {
+		return ccerrors.New(ccerrors.Conflict, "Recipient private data doesn't match certificate "+cert.Id)
+	}
+
+	cert.Recipient = createRecipient(details.Email)
+	recProf := createRecipientProfile(details.PublicKey, details.Name)
+	cert.RecipientProfile = &recProf
+
+	return nil
+}
+
+// Function that reads the certificates index of a recipient in a
+// collection. A recipient without certificates has an empty index.
+func getRecipientIndex(stub shim.ChaincodeStubInterface, collection, email string) (RecipientIndex, string, error) {
+	var index RecipientIndex
+
+	var pepper RecipientPepper
+	err := getPrivateState(stub, collection, RECIPIENT_PEPPER_KEY, &pepper)
+	if isNotFound(err) {
+		return index, "", ccerrors.New(ccerrors.NotFound, "Recipient pepper of "+collection+" is not set, see setRecipientPepper")
+	}
+	if err != nil {
+		return index, "", err
+	}
+
+	indexKey := recipientIndexKey(pepper.Pepper, email)
+	err = getPrivateState(stub, collection, indexKey, &index)
+	if err != nil && !isNotFound(err) {
+		return index, indexKey, err
+	}
+	if index.CertIDs == nil {
+		index.CertIDs = make(map[string]string)
+	}
+	return index, indexKey, nil
+}
+
+// Function that returns the recipient index key of an email:
+// recipient-certs:<hex(HMAC-SHA256(pepper, email))>
+func recipientIndexKey(pepper, email string) string {
+	mac := hmac.New(sha256.New, []byte(pepper))
+	mac.Write([]byte(email))
+	return RECIPIENT_INDEX_PREFIX + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/certificates/go/ccerrors"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+// Typed state access.
+//
+// State is decoded straight into structs and strictly: unknown fields and
+// missing required fields are rejected. A field is required unless its json
+// tag has omitempty (json.Marshal always writes the others).
+//
+// Missing keys return a NOT_FOUND error and undecodable values a
+// DATA_CORRUPTED error, so callers can tell them apart (see isNotFound).
+
+// Function that reads key from the ledger into v (pointer to struct)
+func getState(stub shim.ChaincodeStubInterface, key string, v interface{}) error {
+	stateBytes, err := stub.GetState(key)
+	if err != nil {
+		return ccerrors.New(ccerrors.Internal, "Failed to get state for "+key)
+	}
+	if stateBytes == nil {
+		return ccerrors.New(ccerrors.NotFound, key+" doesn't exist")
+	}
+
+	return decodeState(key, stateBytes, v)
+}
+
+// Function that reads key from a private data collection into v
+func getPrivateState(stub shim.ChaincodeStubInterface, collection, key string, v interface{}) error {
+	stateBytes, err := stub.GetPrivateData(collection, key)
+	if err != nil {
+		return ccerrors.New(ccerrors.Internal, "Failed to get private data for "+key+" in "+collection+": "+err.Error())
+	}
+	if stateBytes == nil {
+		return ccerrors.New(ccerrors.NotFound, key+" doesn't exist in "+collection)
+	}
+
+	return decodeState(key, stateBytes, v)
+}
+
+// Function that checks if key exists in the ledger, whatever its value
+func stateExists(stub shim.ChaincodeStubInterface, key string) (bool, error) {
+	stateBytes, err := stub.GetState(key)
+	if err != nil {
+		return false, ccerrors.New(ccerrors.Internal, "Failed to get state for "+key)
+	}
+	return stateBytes != nil, nil
+}
+
+// Function that strictly decodes the value of key into v
+func decodeState(key string, stateBytes []byte, v interface{}) error {
+	err := checkRequiredFields(stateBytes, reflect.TypeOf(v).Elem())
+	if err != nil {
+		return corruptState(key, err)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(stateBytes))
+	decoder.DisallowUnknownFields()
+	err = decoder.Decode(v)
+	if err != nil {
+		return corruptState(key, err)
+	}
+	if decoder.More() {
+		return corruptState(key, errors.New("trailing data after JSON value"))
+	}
+
+	return nil
+}
+
+func isNotFound(err error) bool {
+	return ccerrors.CodeOf(err) == ccerrors.NotFound
+}
+
+func corruptState(key string, err error) error {
+	logger.Errorf("Corrupt state %s: %s", key, err)
+	return ccerrors.New(ccerrors.DataCorrupted, "State "+key+" can't be decoded: "+err.Error())
+}
+
+// Function that checks that every required field of type t (and of its
+// nested structs) is present in data
+func checkRequiredFields(data json.RawMessage, t reflect.Type) error {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if bytes.Equal(bytes.TrimSpace(data), []byte("null")) {
+		return nil
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		var fields map[string]json.RawMessage
+		err := json.Unmarshal(data, &fields)
+		if err != nil {
+			return err
+		}
+
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			name, omitempty := jsonFieldName(field)
+			if field.PkgPath != "" || name == "-" {
+				// unexported or ignored
+				continue
+			}
+
+			fieldData, ok := fields[name]
+			if !ok {
+				if omitempty {
+					continue
+				}
+				return errors.New("missing required field '" + name + "'")
+			}
+
+			err = checkRequiredFields(fieldData, field.Type)
+			if err != nil {
+				return errors.New(name + ": " + err.Error())
+			}
+		}
+
+	case reflect.Slice, reflect.Array:
+		elemType := t.Elem()
+		for elemType.Kind() == reflect.Ptr {
+			elemType = elemType.Elem()
+		}
+		if elemType.Kind() != reflect.Struct {
+			return nil
+		}
+
+		var elems []json.RawMessage
+		err := json.Unmarshal(data, &elems)
+		if err != nil {
+			return err
+		}
+		for i, elem := range elems {
+			err = checkRequiredFields(elem, elemType)
+			if err != nil {
+				return errors.New("[" + strconv.Itoa(i) + "] " + err.Error())
+			}
+		}
+	}
+
+	return nil
+}
+
+func jsonFieldName(field reflect.StructField) (string, bool) {
+	tag := strings.Split(field.Tag.Get("json"), ",")
+	name := tag[0]
+	if name == "" {
+		name = field.Name
+	}
+
+	omitempty := false
+	for _, option := range tag[1:] {
+		if option == "omitempty" {
+			omitempty = true
+		}
+	}
+	return name, omitempty
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/certificates/go/ccerrors"
+)
+
+func TestDecodeState(t *testing.T) {
+	tests := []struct {
+		name  string
+		state string
+		code  ccerrors.Code
+	}{
+		{"valid", `{"issuerEmail":"issuer1@org1.example.com"}`, ""},
+		{"optional fields", `{"issuerEmail":"issuer1@org1.example.com","badgeIDs":["badge:x"]}`, ""},
+		{"unknown field", `{"issuerEmail":"issuer1@org1.example.com","owner":"x"}`, ccerrors.DataCorrupted},
+		{"missing required field", `{"badgeIDs":[]}`, ccerrors.DataCorrupted},
+		{"invalid JSON", `{"issuerEmail":`, ccerrors.DataCorrupted},
+		{"trailing data", `{"issuerEmail":"issuer1@org1.example.com"} {}`, ccerrors.DataCorrupted},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var summary IssuerSummary
+			err := decodeState("key", []byte(test.state), &summary)
+			if code := ccerrors.CodeOf(err); code != test.code {
+				t.Errorf("expected code %q, got %q (%v)", test.code, code, err)
+			}
+		})
+	}
+}
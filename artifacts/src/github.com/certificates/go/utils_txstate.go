@@ -0,0 +1,107 @@
+package main
+
+import (
+	"sort"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+// Transaction-scoped world state.
+//
+// Fabric's GetState returns the committed value of a key, not the value
+// written earlier in the same transaction. txState wraps the stub of a
+// transaction: writes are buffered, later reads of a key return its
+// buffered value, and reads are cached. The buffer is flushed once, when
+// the transaction succeeds (see Invoke), so the functions can chain steps
+// (create an issuer then a badge, issue several certificates...) without
+// threading their results.
+//
+// Range, partial composite key and rich queries, private data and
+// endorsement policies are not buffered: like in Fabric, they see the
+// committed state.
+
+type txState struct {
+	shim.ChaincodeStubInterface
+
+	// reads are the values read from the stub (nil if missing)
+	reads map[string][]byte
+	// writes are the buffered values (nil to delete), keys in write order
+	writes    map[string][]byte
+	writeKeys []string
+}
+
+func newTxState(stub shim.ChaincodeStubInterface) *txState {
+	return &txState{
+		ChaincodeStubInterface: stub,
+		reads:                  make(map[string][]byte),
+		writes:                 make(map[string][]byte),
+	}
+}
+
+func (s *txState) GetState(key string) ([]byte, error) {
+	if value, ok := s.writes[key]; ok {
+		return value, nil
+	}
+	if value, ok := s.reads[key]; ok {
+		return value, nil
+	}
+
+	value, err := s.ChaincodeStubInterface.GetState(key)
+	if err != nil {
+		return nil, err
+	}
+	s.reads[key] = value
+	return value, nil
+}
+
+func (s *txState) PutState(key string, value []byte) error {
+	// an empty value deletes the key, as in Fabric
+	if len(value) == 0 {
+		return s.DelState(key)
+	}
+	s.write(key, value)
+	return nil
+}
+
+func (s *txState) DelState(key string) error {
+	s.write(key, nil)
+	return nil
+}
+
+func (s *txState) write(key string, value []byte) {
+	if _, ok := s.writes[key]; !ok {
+		s.writeKeys = append(s.writeKeys, key)
+	}
+	s.writes[key] = value
+}
+
+// Function that writes the buffered values to the stub
+func (s *txState) flush() error {
+	logger.Debugf("Transaction %s read set: %q, write set: %q", s.GetTxID(), s.readSet(), s.writeKeys)
+
+	for _, key := range s.writeKeys {
+		var err error
+		if value := s.writes[key]; value == nil {
+			err = s.ChaincodeStubInterface.DelState(key)
+		} else {
+			err = s.ChaincodeStubInterface.PutState(key, value)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	s.writes = make(map[string][]byte)
+	s.writeKeys = nil
+	return nil
+}
+
+// Function that returns the keys read from the stub, sorted
+func (s *txState) readSet() []string {
+	keys := make([]string, 0, len(s.reads))
+	for key := range s.reads {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/certificates/go/cctest"
+)
+
+func TestTxState(t *testing.T) {
+	stub := cctest.NewStub("certificates", new(SimpleChaincode))
+	stub.MockTransactionStart("txstate")
+	defer stub.MockTransactionEnd("txstate")
+
+	state := newTxState(stub)
+	state.PutState("a", []byte("1"))
+	state.PutState("b", []byte("2"))
+	state.DelState("b")
+	state.PutState("c", nil)
+
+	// reads see the buffered writes, which are not written to the stub
+	a, _ := state.GetState("a")
+	b, _ := state.GetState("b")
+	if string(a) != "1" || b != nil || stub.State["a"] != nil {
+		t.Errorf("expected buffered writes, got a=%q b=%q state=%q", a, b, stub.State)
+	}
+
+	fatalOnError(t, state.flush())
+	_, deleted := stub.State["b"]
+	_, empty := stub.State["c"]
+	if string(stub.State["a"]) != "1" || deleted || empty {
+		t.Errorf("expected flushed writes, got %q", stub.State)
+	}
+	if len(state.writeKeys) != 0 {
+		t.Errorf("expected an empty buffer after flush, got %q", state.writeKeys)
+	}
+}
@@ -0,0 +1,189 @@
+// Package validation checks the arguments of the certificates chaincode
+// before they are written to the ledger.
+//
+// A Validator collects every violation, so clients get all the invalid
+// fields of a request at once:
+//
+//	v := validation.New()
+//	v.Email("recipientEmail", email)
+//	v.URL("issuerUrl", url)
+//	v.Name("badgeName", name)
+//	if err := v.Err(); err != nil {
+//		return errorResponse(err)
+//	}
+package validation
+
+import (
+	"net/mail"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/certificates/go/ccerrors"
+)
+
+// Maximum lengths, in characters
+const (
+	MaxEmailLength = 254 // RFC 5321 path limit
+	MaxURLLength   = 2048
+	MaxNameLength  = 256  // names, titles and short labels
+	MaxTextLength  = 4096 // descriptions and criteria
+	MaxKeyLength   = 1024 // public keys, salts and IDs
+
+	// Minimum length of the secrets (recipient salts and peppers), they
+	// must be random to keep the hashed values from being guessed
+	MinSecretLength = 16
+)
+
+// Validator collects field violations
+type Validator struct {
+	details []ccerrors.Detail
+}
+
+// New creates an empty Validator
+func New() *Validator {
+	return &Validator{}
+}
+
+// Add records a violation on field
+func (v *Validator) Add(field, message string) {
+	v.details = append(v.details, ccerrors.Detail{Field: field, Message: message})
+}
+
+// Valid reports whether no violation was recorded
+func (v *Validator) Valid() bool {
+	return len(v.details) == 0
+}
+
+// Err returns an INVALID_ARGUMENT error listing every violation, or nil
+func (v *Validator) Err() error {
+	if v.Valid() {
+		return nil
+	}
+
+	messages := make([]string, len(v.details))
+	for i, detail := range v.details {
+		messages[i] = detail.Field + " " + detail.Message
+	}
+
+	err := ccerrors.New(ccerrors.InvalidArgument, strings.Join(messages, "; "))
+	err.Details = v.details
+	return err
+}
+
+// Required checks that value is not empty (or only spaces)
+func (v *Validator) Required(field, value string) bool {
+	if strings.TrimSpace(value) == "" {
+		v.Add(field, "must not be empty")
+		return false
+	}
+	return true
+}
+
+// Email checks a required RFC 5322 address (addr-spec only, no display name)
+func (v *Validator) Email(field, value string) {
+	if !v.Required(field, value) || !v.maxLength(field, value, MaxEmailLength) {
+		return
+	}
+
+	address, err := mail.ParseAddress(value)
+	if err != nil || address.Name != "" || address.Address != value || !strings.Contains(value, "@") {
+		v.Add(field, "must be a valid email address")
+	}
+}
+
+// URL checks a required absolute http(s) URL
+func (v *Validator) URL(field, value string) {
+	if !v.Required(field, value) || !v.maxLength(field, value, MaxURLLength) || !v.noControl(field, value, false) {
+		return
+	}
+
+	parsed, err := url.Parse(value)
+	if err != nil || !parsed.IsAbs() || parsed.Host == "" ||
+		(parsed.Scheme != "http" && parsed.Scheme != "https") {
+		v.Add(field, "must be an absolute http(s) URL")
+	}
+}
+
+// DateTime checks a required ISO 8601 (RFC 3339) date and time
+func (v *Validator) DateTime(field, value string) {
+	if !v.Required(field, value) {
+		return
+	}
+
+	_, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		v.Add(field, "must be an ISO 8601 date and time (e.g. 2018-10-17T07:29:47Z)")
+	}
+}
+
+// Name checks a required single line text of at most MaxNameLength
+func (v *Validator) Name(field, value string) {
+	if v.Required(field, value) {
+		v.OptionalName(field, value)
+	}
+}
+
+// OptionalName checks a single line text of at most MaxNameLength, it can
+// be empty
+func (v *Validator) OptionalName(field, value string) {
+	if v.maxLength(field, value, MaxNameLength) {
+		v.noControl(field, value, false)
+	}
+}
+
+// Text checks a required multi-line text of at most MaxTextLength
+func (v *Validator) Text(field, value string) {
+	if v.Required(field, value) && v.maxLength(field, value, MaxTextLength) {
+		v.noControl(field, value, true)
+	}
+}
+
+// Key checks an optional single line token (public key, salt, ID) of at
+// most MaxKeyLength
+func (v *Validator) Key(field, value string) {
+	if v.maxLength(field, value, MaxKeyLength) {
+		v.noControl(field, value, false)
+	}
+}
+
+// Secret checks a required single line token (salt, pepper) of at least
+// MinSecretLength and at most MaxKeyLength characters
+func (v *Validator) Secret(field, value string) {
+	if !v.Required(field, value) || !v.maxLength(field, value, MaxKeyLength) || !v.noControl(field, value, false) {
+		return
+	}
+	if utf8.RuneCountInString(value) < MinSecretLength {
+		v.Add(field, "must be at least "+strconv.Itoa(MinSecretLength)+" characters")
+	}
+}
+
+func (v *Validator) maxLength(field, value string, max int) bool {
+	if !utf8.ValidString(value) {
+		v.Add(field, "must be valid UTF-8")
+		return false
+	}
+	if utf8.RuneCountInString(value) > max {
+		v.Add(field, "must be at most "+strconv.Itoa(max)+" characters")
+		return false
+	}
+	return true
+}
+
+// noControl rejects control characters. Multi-line texts can contain
+// line breaks and tabs.
+func (v *Validator) noControl(field, value string, multiline bool) bool {
+	for _, r := range value {
+		if multiline && (r == '\n' || r == '\r' || r == '\t') {
+			continue
+		}
+		if unicode.IsControl(r) {
+			v.Add(field, "must not contain control characters")
+			return false
+		}
+	}
+	return true
+}
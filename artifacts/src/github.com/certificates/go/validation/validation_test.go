@@ -0,0 +1,104 @@
+package validation
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/certificates/go/ccerrors"
+)
+
+// TestBoundaries checks the values accepted and rejected by every check, an
+// empty message means the value is valid
+func TestBoundaries(t *testing.T) {
+	for _, c := range []struct {
+		name    string
+		check   func(v *Validator, field, value string)
+		value   string
+		message string
+	}{
+		{"email", (*Validator).Email, "student@example.com", ""},
+		{"email empty", (*Validator).Email, " ", "must not be empty"},
+		{"email display name", (*Validator).Email, "Student <student@example.com>", "must be a valid email address"},
+		{"email no domain", (*Validator).Email, "student", "must be a valid email address"},
+		{"email max length", (*Validator).Email, "a@" + strings.Repeat("b", MaxEmailLength-4) + ".c", ""},
+		{"email too long", (*Validator).Email, "a@" + strings.Repeat("b", MaxEmailLength-3) + ".c", "must be at most 254 characters"},
+
+		{"url https", (*Validator).URL, "https://org1.example.com/badges?id=1", ""},
+		{"url http", (*Validator).URL, "http://org1.example.com", ""},
+		{"url scheme", (*Validator).URL, "ftp://org1.example.com", "must be an absolute http(s) URL"},
+		{"url relative", (*Validator).URL, "/badges", "must be an absolute http(s) URL"},
+		{"url no host", (*Validator).URL, "https://", "must be an absolute http(s) URL"},
+		{"url control", (*Validator).URL, "https://org1.example.com/\x00", "must not contain control characters"},
+		{"url max length", (*Validator).URL, "https://a.com/" + strings.Repeat("p", MaxURLLength-14), ""},
+		{"url too long", (*Validator).URL, "https://a.com/" + strings.Repeat("p", MaxURLLength-13), "must be at most 2048 characters"},
+
+		{"date time", (*Validator).DateTime, "2018-10-17T07:29:47Z", ""},
+		{"date time offset", (*Validator).DateTime, "2018-10-17T09:29:47+02:00", ""},
+		{"date only", (*Validator).DateTime, "2018-10-17", "must be an ISO 8601 date and time (e.g. 2018-10-17T07:29:47Z)"},
+		{"date time empty", (*Validator).DateTime, "", "must not be empty"},
+
+		{"name", (*Validator).Name, "Data Science", ""},
+		{"name empty", (*Validator).Name, "", "must not be empty"},
+		{"name max length in characters", (*Validator).Name, strings.Repeat("é", MaxNameLength), ""},
+		{"name too long", (*Validator).Name, strings.Repeat("é", MaxNameLength+1), "must be at most 256 characters"},
+		{"name line break", (*Validator).Name, "Data\nScience", "must not contain control characters"},
+		{"name invalid UTF-8", (*Validator).Name, "Data \xff", "must be valid UTF-8"},
+		{"optional name empty", (*Validator).OptionalName, "", ""},
+
+		{"text multi-line", (*Validator).Text, "Line 1\r\nLine 2\twith a tab", ""},
+		{"text control", (*Validator).Text, "Bell \x07", "must not contain control characters"},
+		{"text max length", (*Validator).Text, strings.Repeat("t", MaxTextLength), ""},
+		{"text too long", (*Validator).Text, strings.Repeat("t", MaxTextLength+1), "must be at most 4096 characters"},
+
+		{"key empty", (*Validator).Key, "", ""},
+		{"key max length", (*Validator).Key, strings.Repeat("k", MaxKeyLength), ""},
+		{"key too long", (*Validator).Key, strings.Repeat("k", MaxKeyLength+1), "must be at most 1024 characters"},
+		{"key tab", (*Validator).Key, "key\tvalue", "must not contain control characters"},
+
+		{"secret min length", (*Validator).Secret, strings.Repeat("s", MinSecretLength), ""},
+		{"secret too short", (*Validator).Secret, strings.Repeat("s", MinSecretLength-1), "must be at least 16 characters"},
+		{"secret empty", (*Validator).Secret, "", "must not be empty"},
+		{"secret too long", (*Validator).Secret, strings.Repeat("s", MaxKeyLength+1), "must be at most 1024 characters"},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			v := New()
+			c.check(v, "field", c.value)
+
+			var expected []ccerrors.Detail
+			if c.message != "" {
+				expected = []ccerrors.Detail{{Field: "field", Message: c.message}}
+			}
+			if !reflect.DeepEqual(v.details, expected) {
+				t.Errorf("expected %v, got %v", expected, v.details)
+			}
+		})
+	}
+}
+
+func TestErr(t *testing.T) {
+	v := New()
+	if err := v.Err(); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	v.URL("issuerUrl", "ftp://org1.example.com")
+	v.Name("badgeName", "")
+	v.Name("signatureName", "Dean")
+	err, ok := v.Err().(*ccerrors.Error)
+	if !ok {
+		t.Fatalf("expected a *ccerrors.Error, got %v", v.Err())
+	}
+
+	expected := &ccerrors.Error{
+		Code:    ccerrors.InvalidArgument,
+		Message: "issuerUrl must be an absolute http(s) URL; badgeName must not be empty",
+		Details: []ccerrors.Detail{
+			{Field: "issuerUrl", Message: "must be an absolute http(s) URL"},
+			{Field: "badgeName", Message: "must not be empty"},
+		},
+	}
+	if !reflect.DeepEqual(err, expected) {
+		t.Errorf("expected %v, got %v", expected, err)
+	}
+}
@@ -0,0 +1,224 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/certificates/go/canonicaljson"
+	"github.com/certificates/go/ccerrors"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	pb "github.com/hyperledger/fabric/protos/peer"
+)
+
+// verification failure codes
+const (
+	VERIFY_NOT_FOUND             = "NOT_FOUND"
+	VERIFY_HASH_MISSING          = "HASH_MISSING"
+	VERIFY_HASH_MISMATCH         = "HASH_MISMATCH"
+	VERIFY_REVOKED               = "REVOKED"
+	VERIFY_EXPIRED               = "EXPIRED"
+	VERIFY_ISSUER_NOT_ACCREDITED = "ISSUER_NOT_ACCREDITED"
+	VERIFY_BADGE_NOT_FOUND       = "BADGE_NOT_FOUND"
+	VERIFY_BADGE_MISMATCH        = "BADGE_MISMATCH"
+)
+
+// Structured result of verifyCertificate
+type VerificationVerdict struct {
+	Id               string                `json:"id"`
+	Valid            bool                  `json:"valid"`
+	Exists           bool                  `json:"exists"`
+	Integrity        IntegrityCheck        `json:"integrity"`
+	Revoked          bool                  `json:"revoked"`
+	RevocationReason string                `json:"revocationReason,omitempty"`
+	Expired          bool                  `json:"expired"`
+	Expires          string                `json:"expires,omitempty"`
+	IssuerAccredited bool                  `json:"issuerAccredited"`
+	Badge            BadgeCheck            `json:"badge"`
+	SubjectErased    bool                  `json:"subjectErased"`
+	Failures         []VerificationFailure `json:"failures"`
+}
+
+type IntegrityCheck struct {
+	Valid    bool   `json:"valid"`
+	Stored   string `json:"stored"`
+	Computed string `json:"computed"`
+}
+
+type BadgeCheck struct {
+	Id      string `json:"id"`
+	Version string `json:"version"` // content hash of the badge in ledger
+	Current bool   `json:"current"` // embedded badge matches the ledger badge
+}
+
+type VerificationFailure struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Query that verifies a certificate and returns a VerificationVerdict.
+// Argument: 1) Certificate ID
+//
+// A certificate is valid when it exists, its stored hash matches its
+// content, it isn't revoked (see revokeCertificate) nor expired at the
+// transaction time, its issuer is accredited and its badge is the one in
+// the ledger, owned by the same issuer. Every failing check is listed in
+// Failures.
+//
+// Accreditation only means that the issuer is registered in the ledger
+// (KEY: email), i.e. it issued a badge with a university identity of one
+// of the channel orgs. There is no accreditation authority or revocation
+// of issuers.
+func (t *SimpleChaincode) verifyCertificate(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+
+	if len(args) != 1 {
+		return errorResponse(ccerrors.New(ccerrors.InvalidArgument, "Incorrect number of arguments. Expecting 1: Certificate ID"))
+	}
+
+	verdict := VerificationVerdict{Id: args[0], Failures: []VerificationFailure{}}
+	fail := func(code, message string) {
+		verdict.Failures = append(verdict.Failures, VerificationFailure{Code: code, Message: message})
+	}
+
+	// 1. Existence
+	// ------------
+	var cert Certificate
+	err := getCertificateState(stub, verdict.Id, &cert)
+	if isNotFound(err) || !strings.HasPrefix(verdict.Id, CERT_PREFIX) {
+		fail(VERIFY_NOT_FOUND, "Certificate doesn't exist")
+		return marshalVerdict(verdict)
+	}
+	if err != nil {
+		return errorResponse(err)
+	}
+	verdict.Exists = true
+	verdict.SubjectErased = cert.SubjectErased
+
+	// 2. Integrity
+	// ------------
+	verdict.Integrity.Stored = cert.Hash
+	verdict.Integrity.Computed, err = computeCertificateHash(cert)
+	if err != nil {
+		return errorResponse(err)
+	}
+	if cert.Hash == "" {
+		fail(VERIFY_HASH_MISSING, "Certificate has no stored hash")
+	} else if cert.Hash != verdict.Integrity.Computed {
+		fail(VERIFY_HASH_MISMATCH, "Stored hash doesn't match the certificate content")
+	} else {
+		verdict.Integrity.Valid = true
+	}
+
+	// 3. Revocation and expiry
+	// ------------------------
+	verdict.Revoked, verdict.RevocationReason = cert.Revoked, cert.RevocationReason
+	if cert.Revoked {
+		fail(VERIFY_REVOKED, "Certificate was revoked: "+cert.RevocationReason)
+	}
+
+	verdict.Expires = cert.Expires
+	if cert.Expires != "" {
+		expires, err := time.Parse(time.RFC3339, cert.Expires)
+		if err != nil {
+			return errorResponse(ccerrors.New(ccerrors.Internal, "Invalid expires date in "+verdict.Id))
+		}
+		txTimestamp, err := stub.GetTxTimestamp()
+		if err != nil {
+			return errorResponse(err)
+		}
+		if time.Unix(txTimestamp.Seconds, int64(txTimestamp.Nanos)).After(expires) {
+			verdict.Expired = true
+			fail(VERIFY_EXPIRED, "Certificate expired on "+cert.Expires)
+		}
+	}
+
+	// 4. Issuer accreditation (issuer registered in ledger, KEY: email)
+	// -----------------------------------------------------------------
+	verdict.IssuerAccredited, err = stateExists(stub, cert.Badge.Issuer.Id)
+	if err != nil {
+		return errorResponse(err)
+	}
+	if !verdict.IssuerAccredited {
+		fail(VERIFY_ISSUER_NOT_ACCREDITED, "Issuer "+cert.Badge.Issuer.Id+" is not registered")
+	}
+
+	// 5. Badge version
+	// ----------------
+	verdict.Badge.Id = cert.Badge.Id
+	var badge Badge
+	err = getState(stub, cert.Badge.Id, &badge)
+	if err != nil && !isNotFound(err) {
+		return errorResponse(err)
+	}
+	if isNotFound(err) {
+		fail(VERIFY_BADGE_NOT_FOUND, "Badge "+cert.Badge.Id+" doesn't exist")
+	} else {
+		verdict.Badge.Version, err = computeBadgeHash(badge)
+		if err != nil {
+			return errorResponse(err)
+		}
+		embeddedVersion, err := computeBadgeHash(cert.Badge)
+		if err != nil {
+			return errorResponse(err)
+		}
+
+		verdict.Badge.Current = embeddedVersion == verdict.Badge.Version
+		if !verdict.Badge.Current {
+			fail(VERIFY_BADGE_MISMATCH, "Embedded badge doesn't match badge "+cert.Badge.Id)
+		}
+		if strings.Compare(badge.Issuer.Id, cert.Badge.Issuer.Id) != 0 {
+			fail(VERIFY_BADGE_MISMATCH, "Badge "+cert.Badge.Id+" is not owned by "+cert.Badge.Issuer.Id)
+		}
+	}
+
+	verdict.Valid = len(verdict.Failures) == 0
+
+	return marshalVerdict(verdict)
+}
+
+// Function that computes the certificate integrity hash over its issued
+// content (see getCertificateDigest)
+func computeCertificateHash(cert Certificate) (string, error) {
+	return hashJSON(certificateHashContent(cert))
+}
+
+// Function that returns the issued content of a certificate: mutable status
+// fields (revocation, erasure), the recipient profile and the hash itself
+// are excluded
+func certificateHashContent(cert Certificate) Certificate {
+	cert.Hash = ""
+	cert.Revoked, cert.RevocationReason = false, ""
+	cert.SubjectErased = false
+	cert.RecipientProfile = nil
+	return cert
+}
+
+// Function that computes the content hash (version) of a badge
+func computeBadgeHash(badge Badge) (string, error) {
+	return hashJSON(badge)
+}
+
+// Function that hashes the canonical JSON (RFC 8785) of elem:
+// sha256$<hex>
+func hashJSON(elem interface{}) (string, error) {
+	out, err := canonicaljson.Marshal(elem)
+	if err != nil {
+		return "", err
+	}
+	return hashBytes(out), nil
+}
+
+func hashBytes(data []byte) string {
+	sum := sha256.Sum256(data)
+	return "sha256$" + hex.EncodeToString(sum[:])
+}
+
+func marshalVerdict(verdict VerificationVerdict) pb.Response {
+	out, err := json.Marshal(verdict)
+	if err != nil {
+		return errorResponse(err)
+	}
+	return shim.Success(out)
+}
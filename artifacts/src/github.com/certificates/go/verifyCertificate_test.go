@@ -0,0 +1,65 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/certificates/go/canonicaljson"
+	"github.com/certificates/go/ccerrors"
+	"github.com/certificates/go/cctest"
+)
+
+func TestVerifyCertificate(t *testing.T) {
+	f := newFixture(t)
+
+	var verdict VerificationVerdict
+	f.stub.Run(t, []cctest.Case{
+		{Name: "arguments", Identity: f.issuer2, Function: "verifyCertificate", Code: ccerrors.InvalidArgument},
+		{Name: "not found", Identity: f.issuer2, Function: "verifyCertificate", Args: []string{"cert:nope"},
+			Check: cctest.Decode(&verdict, func() error {
+				return expect(!verdict.Exists && !verdict.Valid, "not found verdict", verdict)
+			})},
+		{Name: "valid", Identity: f.issuer2, Function: "verifyCertificate", Args: []string{f.certID},
+			Check: cctest.Decode(&verdict, func() error {
+				return expect(verdict.Valid && verdict.IssuerAccredited && verdict.Badge.Current, "valid verdict", verdict.Failures)
+			})},
+		{Name: "hash mismatch", Identity: f.issuer2, Function: "verifyCertificate", Args: []string{f.certID},
+			Setup: func(s *cctest.Stub) {
+				var cert Certificate
+				decodeCertificate(s, f.certID, s.State[f.certID], &cert)
+				cert.IssuedOn = "2019-10-17T07:29:47Z"
+				s.State[f.certID], _ = canonicaljson.Marshal(cert)
+			},
+			Check: cctest.Decode(&verdict, func() error {
+				return expect(!verdict.Valid && !verdict.Integrity.Valid && len(verdict.Failures) == 1 &&
+					verdict.Failures[0].Code == VERIFY_HASH_MISMATCH, "hash mismatch", verdict)
+			})},
+		{Name: "missing required field", Identity: f.issuer1, Function: "verifyCertificate", Args: []string{f.certID},
+			Setup: func(s *cctest.Stub) {
+				s.State[f.certID] = []byte(`{"id":"` + f.certID + `"}`)
+			},
+			Code: ccerrors.DataCorrupted},
+	})
+}
+
+func TestVerifyCertificateExpiry(t *testing.T) {
+	f := newLedger(t)
+	f.invoke(t, f.issuer1, nil, "issueBadge", badgeArgs("https://org1.example.com", "Data Science", "")...)
+	f.invoke(t, f.issuer1, f.recipient, "issueCertificate", append(certArgs("data-science"), "2019-10-17T07:29:47Z")...)
+
+	var verdict VerificationVerdict
+	f.stub.Run(t, []cctest.Case{
+		{Name: "not expired", Identity: f.issuer2, Function: "verifyCertificate", Args: []string{f.certID},
+			Check: cctest.Decode(&verdict, func() error {
+				return expect(verdict.Valid && !verdict.Expired && verdict.Expires == "2019-10-17T07:29:47Z", "valid verdict", verdict)
+			})},
+		{Name: "expired", Identity: f.issuer2, Function: "verifyCertificate", Args: []string{f.certID},
+			Setup: func(s *cctest.Stub) {
+				s.Clock = time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)
+			},
+			Check: cctest.Decode(&verdict, func() error {
+				return expect(!verdict.Valid && verdict.Expired && verdict.Integrity.Valid && len(verdict.Failures) == 1 &&
+					verdict.Failures[0].Code == VERIFY_EXPIRED, "expired verdict", verdict)
+			})},
+	})
+}
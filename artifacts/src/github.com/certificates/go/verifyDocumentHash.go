@@ -0,0 +1,162 @@
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/certificates/go/ccerrors"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	pb "github.com/hyperledger/fabric/protos/peer"
+)
+
+// Attached documents index: doc-hash~digest~certID (empty value)
+const DOC_HASH_INDEX = "doc-hash"
+
+type DocumentHashResult struct {
+	Hash         string              `json:"hash"`
+	Certificates []CertificateStatus `json:"certificates"`
+}
+
+type CertificateStatus struct {
+	Id       string `json:"id"`
+	Status   string `json:"status"` // active, revoked, expired or erased
+	Issuer   string `json:"issuer"`
+	Badge    string `json:"badge"`
+	IssuedOn string `json:"issuedOn"`
+}
+
+// Query the certificates a document belongs to.
+// Argument: 1) SHA-256 digest of the document (hex or sha256$hex)
+func (t *SimpleChaincode) verifyDocumentHash(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+
+	if len(args) != 1 {
+		return errorResponse(ccerrors.New(ccerrors.InvalidArgument, "Incorrect number of arguments. Expecting 1: document SHA-256 digest"))
+	}
+
+	digests, err := parseDocumentDigests(strings.TrimPrefix(args[0], "sha256$"))
+	if err != nil || len(digests) != 1 {
+		return errorResponse(ccerrors.New(ccerrors.InvalidArgument, "Invalid document digest, expecting a SHA-256 hex digest"))
+	}
+
+	result := DocumentHashResult{Hash: "sha256$" + digests[0], Certificates: []CertificateStatus{}}
+
+	resultsIterator, err := stub.GetStateByPartialCompositeKey(DOC_HASH_INDEX, []string{digests[0]})
+	if err != nil {
+		return errorResponse(ccerrors.New(ccerrors.Internal, "Failed to search document hash: "+err.Error()))
+	}
+	defer resultsIterator.Close()
+
+	for resultsIterator.HasNext() {
+		indexKV, err := resultsIterator.Next()
+		if err != nil {
+			return errorResponse(err)
+		}
+
+		_, keyParts, err := stub.SplitCompositeKey(indexKV.Key)
+		if err != nil {
+			return errorResponse(err)
+		}
+		certID := keyParts[1]
+
+		var cert Certificate
+		err = getCertificateState(stub, certID, &cert)
+		if isNotFound(err) {
+			logger.Warningf("Indexed certificate %s doesn't exist, skipping...", certID)
+			continue
+		}
+		if err != nil {
+			return errorResponse(err)
+		}
+
+		status, err := getCertificateStatus(stub, cert)
+		if err != nil {
+			return errorResponse(err)
+		}
+
+		result.Certificates = append(result.Certificates, CertificateStatus{
+			Id:       cert.Id,
+			Status:   status,
+			Issuer:   cert.Badge.Issuer.Id,
+			Badge:    cert.Badge.Id,
+			IssuedOn: cert.IssuedOn,
+		})
+	}
+
+	out, err := json.Marshal(result)
+	if err != nil {
+		return errorResponse(err)
+	}
+	return shim.Success(out)
+}
+
+// Function that writes the document hash index entries of a certificate
+func indexDocumentDigests(stub shim.ChaincodeStubInterface, cert Certificate) error {
+	for _, attachment := range cert.Attachments {
+		digest := strings.TrimPrefix(attachment.Digest, "sha256$")
+
+		indexKey, err := stub.CreateCompositeKey(DOC_HASH_INDEX, []string{digest, cert.Id})
+		if err != nil {
+			return errors.New("Error creating index key for " + cert.Id + ": " + err.Error())
+		}
+
+		// value can't be empty (that would delete the key)
+		err = stub.PutState(indexKey, []byte{0x00})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Function that parses a comma separated list of SHA-256 hex digests
+// (lowercased, deduplicated)
+func parseDocumentDigests(digestsArg string) ([]string, error) {
+	var digests []string
+	seen := make(map[string]bool)
+
+	for _, digest := range strings.Split(digestsArg, ",") {
+		digest = strings.ToLower(strings.TrimSpace(digest))
+		if digest == "" || seen[digest] {
+			continue
+		}
+
+		decoded, err := hex.DecodeString(digest)
+		if err != nil || len(decoded) != 32 {
+			return nil, ccerrors.New(ccerrors.InvalidArgument, "Invalid SHA-256 digest: "+digest)
+		}
+
+		seen[digest] = true
+		digests = append(digests, digest)
+	}
+
+	return digests, nil
+}
+
+// Function that returns the current status of a certificate:
+// revoked, erased, expired or active
+func getCertificateStatus(stub shim.ChaincodeStubInterface, cert Certificate) (string, error) {
+	if cert.Revoked {
+		return "revoked", nil
+	}
+	if cert.SubjectErased {
+		return "erased", nil
+	}
+	if cert.Expires != "" {
+		expires, err := time.Parse(time.RFC3339, cert.Expires)
+		if err != nil {
+			return "", errors.New("Invalid expires date in " + cert.Id)
+		}
+		txTimestamp, err := stub.GetTxTimestamp()
+		if err != nil {
+			return "", err
+		}
+		if time.Unix(txTimestamp.Seconds, int64(txTimestamp.Nanos)).After(expires) {
+			return "expired", nil
+		}
+	}
+	return "active", nil
+}
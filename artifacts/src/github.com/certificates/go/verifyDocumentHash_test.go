@@ -0,0 +1,26 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/certificates/go/ccerrors"
+	"github.com/certificates/go/cctest"
+)
+
+func TestVerifyDocumentHash(t *testing.T) {
+	f := newFixture(t)
+
+	var documents DocumentHashResult
+	f.stub.Run(t, []cctest.Case{
+		{Name: "arguments", Identity: f.issuer2, Function: "verifyDocumentHash", Code: ccerrors.InvalidArgument},
+		{Name: "invalid digest", Identity: f.issuer2, Function: "verifyDocumentHash", Args: []string{"abc"}, Code: ccerrors.InvalidArgument},
+		{Name: "attached", Identity: f.issuer2, Function: "verifyDocumentHash", Args: []string{"sha256$" + testDigest},
+			Check: cctest.Decode(&documents, func() error {
+				return expect(len(documents.Certificates) == 1 && documents.Certificates[0].Status == "active", "1 active certificate", documents.Certificates)
+			})},
+		{Name: "unknown document", Identity: f.issuer2, Function: "verifyDocumentHash", Args: []string{"sha256$" + testDigest[1:] + "0"},
+			Check: cctest.Decode(&documents, func() error {
+				return expect(len(documents.Certificates) == 0, "no certificate", documents.Certificates)
+			})},
+	})
+}
@@ -17,8 +17,8 @@ starttime=$(date +%s)
 # Print the usage message
 function printHelp () {
   echo "Usage: "
-  echo "  ./testAPIs.sh -l golang|node"
-  echo "    -l <language> - chaincode language (defaults to \"golang\")"
+  echo "  ./testAPIs.sh -l golang"
+  echo "    -l <language> - chaincode language (defaults to \"golang\", the certificates chaincode is only written in Go)"
 }
 # Language defaults to "golang"
 LANGUAGE="golang"
@@ -40,10 +40,7 @@ function setChaincodePath(){
 	LANGUAGE=`echo "$LANGUAGE" | tr '[:upper:]' '[:lower:]'`
 	case "$LANGUAGE" in
 		"golang")
-		CC_SRC_PATH="github.com/example_cc/go"
-		;;
-		"node")
-		CC_SRC_PATH="$PWD/artifacts/src/github.com/example_cc/node"
+		CC_SRC_PATH="github.com/certificates/go"
 		;;
 		*) printf "\n ------ Language $LANGUAGE is not supported yet ------\n"$
 		exit 1
@@ -52,12 +49,23 @@ function setChaincodePath(){
 
 setChaincodePath
 
+# private data collections of the recipients, relative to app/
+COLLECTIONS_CONFIG_PATH="../artifacts/src/github.com/certificates/go/collections_config.json"
+
 echo "POST request Enroll on Org1  ..."
 echo
 ORG1_TOKEN=$(curl -s -X POST \
-  http://localhost:4000/users \
-  -H "content-type: application/x-www-form-urlencoded" \
-  -d 'username=Jim&orgName=Org1')
+  http://localhost:4000/newUser \
+  -H "content-type: application/json" \
+  -d '{
+	"username":"Jim",
+	"orgName":"Org1",
+	"attrs":[
+		{"name":"role","value":"university","ecert":true},
+		{"name":"email","value":"jim@org1.example.com","ecert":true},
+		{"name":"admin","value":"true","ecert":true}
+	]
+}')
 echo $ORG1_TOKEN
 ORG1_TOKEN=$(echo $ORG1_TOKEN | jq ".token" | sed "s/\"//g")
 echo
@@ -66,9 +74,17 @@ echo
 echo "POST request Enroll on Org2 ..."
 echo
 ORG2_TOKEN=$(curl -s -X POST \
-  http://localhost:4000/users \
-  -H "content-type: application/x-www-form-urlencoded" \
-  -d 'username=Barry&orgName=Org2')
+  http://localhost:4000/newUser \
+  -H "content-type: application/json" \
+  -d '{
+	"username":"Barry",
+	"orgName":"Org2",
+	"attrs":[
+		{"name":"role","value":"university","ecert":true},
+		{"name":"email","value":"barry@org2.example.com","ecert":true},
+		{"name":"admin","value":"true","ecert":true}
+	]
+}')
 echo $ORG2_TOKEN
 ORG2_TOKEN=$(echo $ORG2_TOKEN | jq ".token" | sed "s/\"//g")
 echo
@@ -120,7 +136,7 @@ curl -s -X POST \
   -H "content-type: application/json" \
   -d "{
 	\"peers\": [\"peer0.org1.example.com\",\"peer1.org1.example.com\"],
-	\"chaincodeName\":\"mycc\",
+	\"chaincodeName\":\"certificates\",
 	\"chaincodePath\":\"$CC_SRC_PATH\",
 	\"chaincodeType\": \"$LANGUAGE\",
 	\"chaincodeVersion\":\"v0\"
@@ -136,7 +152,7 @@ curl -s -X POST \
   -H "content-type: application/json" \
   -d "{
 	\"peers\": [\"peer0.org2.example.com\",\"peer1.org2.example.com\"],
-	\"chaincodeName\":\"mycc\",
+	\"chaincodeName\":\"certificates\",
 	\"chaincodePath\":\"$CC_SRC_PATH\",
 	\"chaincodeType\": \"$LANGUAGE\",
 	\"chaincodeVersion\":\"v0\"
@@ -151,33 +167,83 @@ curl -s -X POST \
   -H "authorization: Bearer $ORG1_TOKEN" \
   -H "content-type: application/json" \
   -d "{
-	\"chaincodeName\":\"mycc\",
+	\"chaincodeName\":\"certificates\",
 	\"chaincodeVersion\":\"v0\",
 	\"chaincodeType\": \"$LANGUAGE\",
-	\"args\":[\"a\",\"100\",\"b\",\"200\"]
+	\"collectionsConfigPath\":\"$COLLECTIONS_CONFIG_PATH\",
+	\"args\":[]
 }"
 echo
 echo
 
-echo "POST invoke chaincode on peers of Org1 and Org2"
+echo "POST invoke initLedger on peers of Org1 and Org2"
 echo
-TRX_ID=$(curl -s -X POST \
-  http://localhost:4000/channels/mychannel/chaincodes/mycc \
+curl -s -X POST \
+  http://localhost:4000/channels/mychannel/chaincodes/certificates \
   -H "authorization: Bearer $ORG1_TOKEN" \
   -H "content-type: application/json" \
   -d '{
 	"peers": ["peer0.org1.example.com","peer0.org2.example.com"],
-	"fcn":"move",
-	"args":["a","b","10"]
-}')
+	"fcn":"initLedger",
+	"args":[]
+}'
+echo
+echo
+
+# The pepper and the recipient salt must be random, they are sent in the
+# transient map and never reach the ledger
+PEPPER=$(openssl rand -hex 32)
+SALT=$(openssl rand -hex 32)
+
+echo "POST invoke setRecipientPepper on peers of Org1"
+echo
+curl -s -X POST \
+  http://localhost:4000/channels/mychannel/chaincodes/certificates \
+  -H "authorization: Bearer $ORG1_TOKEN" \
+  -H "content-type: application/json" \
+  -d "{
+	\"peers\": [\"peer0.org1.example.com\"],
+	\"fcn\":\"setRecipientPepper\",
+	\"args\":[],
+	\"transient\": {\"pepper\": \"$PEPPER\"}
+}"
+echo
+echo
+
+echo "POST invoke issueBadge on peers of Org1 and Org2"
+echo
+curl -s -X POST \
+  http://localhost:4000/channels/mychannel/chaincodes/certificates \
+  -H "authorization: Bearer $ORG1_TOKEN" \
+  -H "content-type: application/json" \
+  -d '{
+	"peers": ["peer0.org1.example.com","peer0.org2.example.com"],
+	"fcn":"issueBadge",
+	"args":["Org1 University","https://org1.example.com","Data Science","Badge description","Badge criteria","Dean","Signature","data,python","science","advanced","en",""]
+}'
+echo
+echo
+
+echo "POST invoke issueCertificate on peers of Org1"
+echo
+TRX_ID=$(curl -s -X POST \
+  http://localhost:4000/channels/mychannel/chaincodes/certificates \
+  -H "authorization: Bearer $ORG1_TOKEN" \
+  -H "content-type: application/json" \
+  -d "{
+	\"peers\": [\"peer0.org1.example.com\"],
+	\"fcn\":\"issueCertificate\",
+	\"args\":[\"2018-10-17T07:29:47Z\",\"https://org1.example.com/verify\",\"data-science\"],
+	\"transient\": {\"recipient\": {\"email\":\"student@example.com\",\"name\":\"Student\",\"publicKey\":\"ecdsa-koblitz-pubkey:test\",\"salt\":\"$SALT\"}}
+}" | jq -r ".transactionID")
 echo "Transaction ID is $TRX_ID"
 echo
 echo
 
-echo "GET query chaincode on peer1 of Org1"
+echo "GET query listCertificates on peer0 of Org1"
 echo
 curl -s -X GET \
-  "http://localhost:4000/channels/mychannel/chaincodes/mycc?peer=peer0.org1.example.com&fcn=query&args=%5B%22a%22%5D" \
+  "http://localhost:4000/channels/mychannel/chaincodes/certificates?peer=peer0.org1.example.com&fcn=listCertificates&args=%5B%2210%22%2C%22%22%5D" \
   -H "authorization: Bearer $ORG1_TOKEN" \
   -H "content-type: application/json"
 echo